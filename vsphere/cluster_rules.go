@@ -0,0 +1,251 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+// clusterObjectID returns the ID of a group or rule of a cluster, such as "domain-c7:db-hosts".
+func clusterObjectID(clusterID, key string) string {
+	return clusterID + ":" + key
+}
+
+// parseClusterObjectID parses the ID of a group or rule of a cluster.
+func parseClusterObjectID(id string) (string, string, error) {
+	i := strings.Index(id, ":")
+	if i < 1 || i == len(id)-1 {
+		return "", "", fmt.Errorf("Invalid ID %q: it must be like <cluster ID>:<key>.", id)
+	}
+	return id[:i], id[i+1:], nil
+}
+
+// getClusterConfig gets the configuration of a cluster with its groups and rules.
+func getClusterConfig(c *govmomi.Client, ref types.ManagedObjectReference) (*types.ClusterConfigInfoEx, error) {
+	var mcc mo.ClusterComputeResource
+	cluster := object.NewClusterComputeResource(c.Client, ref)
+	if err := cluster.Properties(context.TODO(), ref, []string{"configurationEx"}, &mcc); err != nil {
+		return nil, err
+	}
+	config, ok := mcc.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected configuration of cluster %s: %#v", ref.Value, mcc.ConfigurationEx)
+	}
+	return config, nil
+}
+
+// reconfigureCluster applies the changes of spec to a cluster. Only the groups
+// and rules in spec are changed, so that the other groups and rules are kept.
+func reconfigureCluster(c *govmomi.Client, ref types.ManagedObjectReference, spec *types.ClusterConfigSpecEx) error {
+	log.Printf("[DEBUG] cluster config spec: %#v", spec)
+	task, err := object.NewComputeResource(c.Client, ref).Reconfigure(context.TODO(), spec, true)
+	return waitForTask(task, err)
+}
+
+// findClusterGroup finds a group of a cluster by its name. It returns nil if not found.
+func findClusterGroup(config *types.ClusterConfigInfoEx, name string) types.BaseClusterGroupInfo {
+	for _, g := range config.Group {
+		if g.GetClusterGroupInfo().Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// findClusterRule finds a rule of a cluster by its key. It returns nil if not found.
+func findClusterRule(config *types.ClusterConfigInfoEx, key int) types.BaseClusterRuleInfo {
+	for _, r := range config.Rule {
+		if r.GetClusterRuleInfo().Key == key {
+			return r
+		}
+	}
+	return nil
+}
+
+// findClusterRuleByName finds a rule of a cluster by its name. It returns nil if not found.
+func findClusterRuleByName(config *types.ClusterConfigInfoEx, name string) types.BaseClusterRuleInfo {
+	for _, r := range config.Rule {
+		if r.GetClusterRuleInfo().Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// addClusterRule adds a rule to a cluster and returns the key assigned to the rule.
+// The rule is found by its name after it's added, so the name must be unique in the cluster.
+func addClusterRule(c *govmomi.Client, ref types.ManagedObjectReference, rule types.BaseClusterRuleInfo) (int, error) {
+	name := rule.GetClusterRuleInfo().Name
+	config, err := getClusterConfig(c, ref)
+	if err != nil {
+		return 0, err
+	}
+	if findClusterRuleByName(config, name) != nil {
+		return 0, fmt.Errorf("Rule %s already exists in cluster %s.", name, ref.Value)
+	}
+
+	spec := &types.ClusterConfigSpecEx{
+		RulesSpec: []types.ClusterRuleSpec{
+			types.ClusterRuleSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info:            rule,
+			},
+		},
+	}
+	if err := reconfigureCluster(c, ref, spec); err != nil {
+		return 0, err
+	}
+
+	config, err = getClusterConfig(c, ref)
+	if err != nil {
+		return 0, err
+	}
+	added := findClusterRuleByName(config, name)
+	if added == nil {
+		return 0, fmt.Errorf("Rule %s is not found in cluster %s after it's added.", name, ref.Value)
+	}
+	return added.GetClusterRuleInfo().Key, nil
+}
+
+// editClusterRule replaces a rule of a cluster with the same key.
+func editClusterRule(c *govmomi.Client, ref types.ManagedObjectReference, rule types.BaseClusterRuleInfo) error {
+	return reconfigureCluster(c, ref, &types.ClusterConfigSpecEx{
+		RulesSpec: []types.ClusterRuleSpec{
+			types.ClusterRuleSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationEdit},
+				Info:            rule,
+			},
+		},
+	})
+}
+
+// removeClusterRule removes a rule of a cluster by its key.
+func removeClusterRule(c *govmomi.Client, ref types.ManagedObjectReference, key int) error {
+	return reconfigureCluster(c, ref, &types.ClusterConfigSpecEx{
+		RulesSpec: []types.ClusterRuleSpec{
+			types.ClusterRuleSpec{
+				// The key of a rule is xsd:int, which is int32 in Go.
+				ArrayUpdateSpec: types.ArrayUpdateSpec{
+					Operation: types.ArrayUpdateOperationRemove,
+					RemoveKey: int32(key),
+				},
+			},
+		},
+	})
+}
+
+// setClusterGroup adds or edits a group of a cluster.
+func setClusterGroup(c *govmomi.Client, ref types.ManagedObjectReference, group types.BaseClusterGroupInfo, operation types.ArrayUpdateOperation) error {
+	return reconfigureCluster(c, ref, &types.ClusterConfigSpecEx{
+		GroupSpec: []types.ClusterGroupSpec{
+			types.ClusterGroupSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: operation},
+				Info:            group,
+			},
+		},
+	})
+}
+
+// removeClusterGroup removes a group of a cluster by its name.
+func removeClusterGroup(c *govmomi.Client, ref types.ManagedObjectReference, name string) error {
+	return reconfigureCluster(c, ref, &types.ClusterConfigSpecEx{
+		GroupSpec: []types.ClusterGroupSpec{
+			types.ClusterGroupSpec{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{
+					Operation: types.ArrayUpdateOperationRemove,
+					RemoveKey: name,
+				},
+			},
+		},
+	})
+}
+
+// findVirtualMachineReferences finds virtual machines in the datacenter of a
+// cluster by their paths relative to the VM folder, such as "prod/db-1".
+func findVirtualMachineReferences(c *govmomi.Client, cluster types.ManagedObjectReference, paths *schema.Set) ([]types.ManagedObjectReference, error) {
+	finder, err := datacenterFinder(c, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []types.ManagedObjectReference
+	for _, p := range stringSet(paths) {
+		vm, err := finder.VirtualMachine(context.TODO(), p)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, vm.Reference())
+	}
+	return refs, nil
+}
+
+// findDatacenterVirtualMachine finds a virtual machine in the datacenter of a
+// managed entity by its path relative to the VM folder.
+func findDatacenterVirtualMachine(c *govmomi.Client, entity types.ManagedObjectReference, p string) (*object.VirtualMachine, error) {
+	finder, err := datacenterFinder(c, entity)
+	if err != nil {
+		return nil, err
+	}
+	return finder.VirtualMachine(context.TODO(), p)
+}
+
+// datacenterFinder returns a Finder for the datacenter of a managed entity.
+func datacenterFinder(c *govmomi.Client, entity types.ManagedObjectReference) (*find.Finder, error) {
+	dc, err := getEntityDatacenter(c, entity)
+	if err != nil {
+		return nil, err
+	}
+	finder := find.NewFinder(c.Client, true)
+	return finder.SetDatacenter(dc), nil
+}
+
+// virtualMachinePaths returns the paths of virtual machines relative to the VM folder of their datacenter.
+func virtualMachinePaths(c *govmomi.Client, refs []types.ManagedObjectReference) ([]string, error) {
+	var paths []string
+	for _, ref := range refs {
+		entities, err := mo.Ancestors(context.TODO(), c.Client, c.ServiceContent.PropertyCollector, ref)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := vmFolderRelativePath(entities)
+		if !ok {
+			return nil, fmt.Errorf("Virtual machine %s is not in the VM folder of a datacenter.", ref.Value)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// vmFolderRelativePath returns the path of the last entity relative to the VM
+// folder of its datacenter. The entities are the ancestors from the root folder.
+func vmFolderRelativePath(entities []mo.ManagedEntity) (string, bool) {
+	for i, e := range entities {
+		// The VM folder is the child of the datacenter.
+		if e.Self.Type == "Datacenter" && i+2 < len(entities) {
+			var names []string
+			for _, e := range entities[i+2:] {
+				names = append(names, e.Name)
+			}
+			return path.Join(names...), true
+		}
+	}
+	return "", false
+}
+
+// hostSystemReferences returns the references of hosts by their managed object IDs.
+func hostSystemReferences(ids *schema.Set) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+	for _, id := range stringSet(ids) {
+		refs = append(refs, hostSystemReference(id))
+	}
+	return refs
+}
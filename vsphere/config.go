@@ -14,13 +14,24 @@ const (
 )
 
 type Config struct {
-	User          string
-	Password      string
-	VCenterServer string
+	User               string
+	Password           string
+	VCenterServer      string
+	DefaultDNSServers  []string
+	DefaultDNSSuffixes []string
+}
+
+// VSphereClient is the meta object passed to the resources. It holds the
+// vSphere API clients and the provider-level defaults.
+type VSphereClient struct {
+	vimClient          *govmomi.Client
+	restClient         *restClient
+	defaultDNSServers  []string
+	defaultDNSSuffixes []string
 }
 
 // Client() returns a new client for accessing VMWare vSphere.
-func (c *Config) Client() (*govmomi.Client, error) {
+func (c *Config) Client() (*VSphereClient, error) {
 	u, err := url.Parse("https://" + c.VCenterServer + "/sdk")
 	if err != nil {
 		return nil, fmt.Errorf("Error parse url: %s", err)
@@ -35,5 +46,15 @@ func (c *Config) Client() (*govmomi.Client, error) {
 
 	log.Printf("[INFO] VMWare vSphere Client configured for URL: %s", u)
 
-	return client, nil
+	restClient, err := newRestClient(c.VCenterServer, c.User, c.Password, defaultInsecureFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VSphereClient{
+		vimClient:          client,
+		restClient:         restClient,
+		defaultDNSServers:  c.DefaultDNSServers,
+		defaultDNSSuffixes: c.DefaultDNSSuffixes,
+	}, nil
 }
@@ -0,0 +1,470 @@
+package vsphere
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	contentLibraryItemTypeOvf  = "ovf"
+	contentLibraryItemTypeIso  = "iso"
+	contentLibraryItemTypeFile = "file"
+
+	contentLibraryUploadTimeout = 60 * time.Minute
+)
+
+type contentLibraryStorageBacking struct {
+	Type        string `json:"type"`
+	DatastoreID string `json:"datastore_id,omitempty"`
+}
+
+type contentLibraryPublishInfo struct {
+	Published            bool   `json:"published"`
+	AuthenticationMethod string `json:"authentication_method,omitempty"`
+	PublishURL           string `json:"publish_url,omitempty"`
+}
+
+type contentLibrarySubscriptionInfo struct {
+	SubscriptionURL      string `json:"subscription_url"`
+	AuthenticationMethod string `json:"authentication_method"`
+	UserName             string `json:"user_name,omitempty"`
+	Password             string `json:"password,omitempty"`
+	AutomaticSyncEnabled bool   `json:"automatic_sync_enabled"`
+	OnDemand             bool   `json:"on_demand"`
+}
+
+// contentLibrary is a library of the Content Library API.
+type contentLibrary struct {
+	ID               string                          `json:"id,omitempty"`
+	Name             string                          `json:"name,omitempty"`
+	Description      string                          `json:"description,omitempty"`
+	Type             string                          `json:"type,omitempty"`
+	StorageBackings  []contentLibraryStorageBacking  `json:"storage_backings,omitempty"`
+	PublishInfo      *contentLibraryPublishInfo      `json:"publish_info,omitempty"`
+	SubscriptionInfo *contentLibrarySubscriptionInfo `json:"subscription_info,omitempty"`
+}
+
+// contentLibraryItem is a library item of the Content Library API.
+type contentLibraryItem struct {
+	ID          string `json:"id,omitempty"`
+	LibraryID   string `json:"library_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// contentLibraryPath returns the API path of a local or subscribed library.
+func contentLibraryPath(subscribed bool) string {
+	if subscribed {
+		return "/com/vmware/content/subscribed-library"
+	}
+	return "/com/vmware/content/local-library"
+}
+
+// createContentLibrary creates a local or subscribed library and returns its ID.
+func createContentLibrary(c *restClient, library contentLibrary) (string, error) {
+	var id string
+	body := map[string]interface{}{"create_spec": library}
+	err := c.do("POST", contentLibraryPath(library.SubscriptionInfo != nil), body, &id)
+	return id, err
+}
+
+// getContentLibrary gets a library by its ID.
+func getContentLibrary(c *restClient, id string) (*contentLibrary, error) {
+	var library contentLibrary
+	if err := c.do("GET", "/com/vmware/content/library/id:"+id, nil, &library); err != nil {
+		return nil, err
+	}
+	return &library, nil
+}
+
+// updateContentLibrary updates a local or subscribed library.
+func updateContentLibrary(c *restClient, id string, subscribed bool, library contentLibrary) error {
+	body := map[string]interface{}{"update_spec": library}
+	return c.do("PATCH", contentLibraryPath(subscribed)+"/id:"+id, body, nil)
+}
+
+// deleteContentLibrary deletes a local or subscribed library.
+func deleteContentLibrary(c *restClient, id string, subscribed bool) error {
+	return c.do("DELETE", contentLibraryPath(subscribed)+"/id:"+id, nil, nil)
+}
+
+// createContentLibraryItem creates an empty library item and returns its ID.
+func createContentLibraryItem(c *restClient, item contentLibraryItem) (string, error) {
+	var id string
+	body := map[string]interface{}{"create_spec": item}
+	err := c.do("POST", "/com/vmware/content/library/item", body, &id)
+	return id, err
+}
+
+// getContentLibraryItem gets a library item by its ID.
+func getContentLibraryItem(c *restClient, id string) (*contentLibraryItem, error) {
+	var item contentLibraryItem
+	if err := c.do("GET", "/com/vmware/content/library/item/id:"+id, nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// updateContentLibraryItem updates the name and description of a library item.
+func updateContentLibraryItem(c *restClient, id string, item contentLibraryItem) error {
+	body := map[string]interface{}{"update_spec": item}
+	return c.do("PATCH", "/com/vmware/content/library/item/id:"+id, body, nil)
+}
+
+// deleteContentLibraryItem deletes a library item.
+func deleteContentLibraryItem(c *restClient, id string) error {
+	return c.do("DELETE", "/com/vmware/content/library/item/id:"+id, nil, nil)
+}
+
+// contentLibraryItemType returns the library item type for a file by its extension.
+func contentLibraryItemType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".ova", ".ovf":
+		return contentLibraryItemTypeOvf
+	case ".iso":
+		return contentLibraryItemTypeIso
+	}
+	return contentLibraryItemTypeFile
+}
+
+// ovfFileReferences returns the files referenced by an OVF descriptor.
+func ovfFileReferences(descriptor string) ([]string, error) {
+	var envelope struct {
+		References struct {
+			File []struct {
+				Href string `xml:"href,attr"`
+			} `xml:"File"`
+		} `xml:"References"`
+	}
+	if err := xml.Unmarshal([]byte(descriptor), &envelope); err != nil {
+		return nil, fmt.Errorf("Failed to parse OVF descriptor: %s", err)
+	}
+	var files []string
+	for _, f := range envelope.References.File {
+		files = append(files, f.Href)
+	}
+	return files, nil
+}
+
+// contentLibraryFile is a file to upload to a library item.
+type contentLibraryFile struct {
+	name string
+	open func() (io.ReadCloser, int64, error)
+}
+
+// uploadContentLibraryItem uploads files to a library item in an update session.
+// The session is canceled if an upload fails.
+func uploadContentLibraryItem(c *restClient, itemID string, files []contentLibraryFile) error {
+	var session string
+	body := map[string]interface{}{
+		"create_spec": map[string]string{"library_item_id": itemID},
+	}
+	if err := c.do("POST", "/com/vmware/content/library/item/update-session", body, &session); err != nil {
+		return err
+	}
+	sessionPath := "/com/vmware/content/library/item/update-session/id:" + session
+
+	if err := uploadContentLibraryFiles(c, session, files); err != nil {
+		if e := c.do("POST", sessionPath+"?~action=cancel", nil, nil); e != nil {
+			log.Printf("[ERROR] Failed to cancel update session: %s", e)
+		}
+		return err
+	}
+
+	if err := c.do("POST", sessionPath+"?~action=complete", nil, nil); err != nil {
+		return err
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"ACTIVE"},
+		Target:     []string{"DONE"},
+		Refresh:    contentLibraryUpdateSessionState(c, sessionPath),
+		Timeout:    contentLibraryUploadTimeout,
+		MinTimeout: 2 * time.Second,
+	}
+	if _, err := stateConf.WaitForState(); err != nil {
+		return err
+	}
+	return c.do("DELETE", sessionPath, nil, nil)
+}
+
+// uploadContentLibraryFiles adds files to an update session and uploads them.
+func uploadContentLibraryFiles(c *restClient, session string, files []contentLibraryFile) error {
+	for _, f := range files {
+		r, size, err := f.open()
+		if err != nil {
+			return err
+		}
+
+		var info struct {
+			UploadEndpoint struct {
+				URI string `json:"uri"`
+			} `json:"upload_endpoint"`
+		}
+		body := map[string]interface{}{
+			"file_spec": map[string]interface{}{
+				"name":        f.name,
+				"source_type": "PUSH",
+				"size":        size,
+			},
+		}
+		err = c.do("POST", "/com/vmware/content/library/item/updatesession/file/id:"+session+"?~action=add", body, &info)
+		if err == nil {
+			log.Printf("[DEBUG] uploading %s (%d bytes) to %s", f.name, size, info.UploadEndpoint.URI)
+			err = c.upload(info.UploadEndpoint.URI, r, size)
+		}
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contentLibraryUpdateSessionState returns the state of an update session.
+func contentLibraryUpdateSessionState(c *restClient, sessionPath string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		var session struct {
+			State        string `json:"state"`
+			ErrorMessage *struct {
+				DefaultMessage string `json:"default_message"`
+			} `json:"error_message"`
+		}
+		if err := c.do("GET", sessionPath, nil, &session); err != nil {
+			return nil, "", err
+		}
+		if session.State == "ERROR" || session.State == "CANCELED" {
+			message := session.State
+			if session.ErrorMessage != nil {
+				message = session.ErrorMessage.DefaultMessage
+			}
+			return nil, "", fmt.Errorf("Failed to upload library item: %s", message)
+		}
+		return session, session.State, nil
+	}
+}
+
+// ovfLibraryFiles returns the files to upload an OVF or OVA package to a library item.
+// The descriptor is uploaded as <name>.ovf, followed by the files referenced by it.
+func ovfLibraryFiles(archive ovfArchive, name string) ([]contentLibraryFile, error) {
+	descriptor, err := archive.descriptor()
+	if err != nil {
+		return nil, err
+	}
+	references, err := ovfFileReferences(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []contentLibraryFile{
+		{
+			name: name + ".ovf",
+			open: func() (io.ReadCloser, int64, error) {
+				return ioutil.NopCloser(strings.NewReader(descriptor)), int64(len(descriptor)), nil
+			},
+		},
+	}
+	for _, ref := range references {
+		ref := ref
+		files = append(files, contentLibraryFile{
+			name: ref,
+			open: func() (io.ReadCloser, int64, error) {
+				return archive.open(ref)
+			},
+		})
+	}
+	return files, nil
+}
+
+// contentLibraryItemFiles returns the files to upload to a library item from
+// a local path or an HTTP(S) URL. An OVF package is uploaded as an OVF
+// template and any other file is uploaded as it is.
+func contentLibraryItemFiles(source, itemType, name string, allowUnverifiedSSL bool) ([]contentLibraryFile, error) {
+	remote := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+
+	if itemType == contentLibraryItemTypeOvf {
+		s := &ovfSource{allowUnverifiedSSL: allowUnverifiedSSL}
+		if remote {
+			s.remoteURL = source
+		} else {
+			s.localPath = source
+		}
+		archive, err := s.newOvfArchive()
+		if err != nil {
+			return nil, err
+		}
+		return ovfLibraryFiles(archive, name)
+	}
+
+	var file contentLibraryFile
+	if remote {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		client := newDownloadClient(allowUnverifiedSSL)
+		file.name = path.Base(u.Path)
+		file.open = func() (io.ReadCloser, int64, error) {
+			return httpGet(client, u)
+		}
+	} else {
+		file.name = filepath.Base(source)
+		file.open = func() (io.ReadCloser, int64, error) {
+			return openLocalFile(source)
+		}
+	}
+	return []contentLibraryFile{file}, nil
+}
+
+// ovfLibraryItemPropertyParams is the property parameters of an OVF library item.
+type ovfLibraryItemPropertyParams struct {
+	Class      string `json:"@class"`
+	Type       string `json:"type"`
+	Properties []struct {
+		ID    string `json:"id"`
+		Value string `json:"value,omitempty"`
+	} `json:"properties"`
+}
+
+// deployLibraryItem deploys a new VirtualMachine from an OVF template in a content library.
+func (vm *virtualMachine) deployLibraryItem(c *VSphereClient) error {
+	dc, err := getDatacenter(c.vimClient, vm.datacenter)
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(c.vimClient.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	resourcePool, err := vm.findResourcePool(finder)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] resource pool: %#v", resourcePool)
+
+	folder, err := getVMFolder(c.vimClient, dc, vm.folder)
+	if err != nil {
+		return err
+	}
+
+	datastore, err := vm.findImportDatastore(c.vimClient, dc, finder)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] datastore: %#v", datastore)
+
+	target := map[string]string{
+		"resource_pool_id": resourcePool.Reference().Value,
+		"folder_id":        folder.Reference().Value,
+	}
+	host, err := vm.findHost(finder)
+	if err != nil {
+		return err
+	}
+	if host != nil {
+		target["host_id"] = host.Reference().Value
+	}
+	itemPath := "/com/vmware/vcenter/ovf/library-item/id:" + vm.contentLibraryItem
+
+	var filter struct {
+		Networks         []string                       `json:"networks"`
+		AdditionalParams []ovfLibraryItemPropertyParams `json:"additional_params"`
+	}
+	if err := c.restClient.do("POST", itemPath+"?~action=filter", map[string]interface{}{"target": target}, &filter); err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] library item filter: %#v", filter)
+
+	var networks []types.OvfNetworkInfo
+	for _, n := range filter.Networks {
+		networks = append(networks, types.OvfNetworkInfo{Name: n})
+	}
+	networkMapping, err := createOvfNetworkMapping(c.vimClient, finder, networks, nil, vm.networkInterfaces)
+	if err != nil {
+		return err
+	}
+	var networkMappings []map[string]string
+	for _, m := range networkMapping {
+		networkMappings = append(networkMappings, map[string]string{
+			"key":   m.Name,
+			"value": m.Network.Value,
+		})
+	}
+
+	var additionalParams []ovfLibraryItemPropertyParams
+	var valid []string
+	for _, p := range filter.AdditionalParams {
+		if p.Type != "PropertyParams" {
+			continue
+		}
+		for i := range p.Properties {
+			valid = append(valid, p.Properties[i].ID)
+			if v, ok := vm.vAppProperties[p.Properties[i].ID]; ok {
+				p.Properties[i].Value = v
+			}
+		}
+		additionalParams = append(additionalParams, p)
+	}
+	if err := validateVAppPropertyKeys(vm.vAppProperties, valid); err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"target": target,
+		"deployment_spec": map[string]interface{}{
+			"name":                  vm.name,
+			"accept_all_EULA":       true,
+			"default_datastore_id":  datastore.Reference().Value,
+			"storage_provisioning":  ovfDiskProvisioningThin,
+			"network_mappings":      networkMappings,
+			"additional_parameters": additionalParams,
+		},
+	}
+	var result struct {
+		Succeeded  bool `json:"succeeded"`
+		ResourceID struct {
+			ID string `json:"id"`
+		} `json:"resource_id"`
+		Error *struct {
+			Errors []struct {
+				Error struct {
+					DefaultMessage string `json:"default_message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := c.restClient.do("POST", itemPath+"?~action=deploy", body, &result); err != nil {
+		return err
+	}
+	if !result.Succeeded {
+		var messages []string
+		if result.Error != nil {
+			for _, e := range result.Error.Errors {
+				messages = append(messages, e.Error.DefaultMessage)
+			}
+		}
+		return fmt.Errorf("Failed to deploy library item %s: %s", vm.contentLibraryItem, strings.Join(messages, "; "))
+	}
+	vm.created = true
+
+	newVM := object.NewVirtualMachine(c.vimClient.Client, types.ManagedObjectReference{
+		Type:  "VirtualMachine",
+		Value: result.ResourceID.ID,
+	})
+	log.Printf("[DEBUG] new vm: %v", newVM)
+
+	return vm.finishImport(c.vimClient, newVM, ovfDiskProvisioningThin)
+}
@@ -0,0 +1,43 @@
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContentLibraryItemType(t *testing.T) {
+	cases := map[string]string{
+		"/tmp/appliance.ova":             contentLibraryItemTypeOvf,
+		"https://example.com/app.OVF":    contentLibraryItemTypeOvf,
+		"/tmp/ubuntu.iso":                contentLibraryItemTypeIso,
+		"https://example.com/readme.txt": contentLibraryItemTypeFile,
+	}
+	for name, expected := range cases {
+		if v := contentLibraryItemType(name); v != expected {
+			t.Fatalf("%s: expected %q, got %q", name, expected, v)
+		}
+	}
+}
+
+func TestOvfFileReferences(t *testing.T) {
+	descriptor := `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:href="appliance-disk1.vmdk" ovf:id="file1" ovf:size="1024"/>
+    <File ovf:href="appliance-disk2.vmdk" ovf:id="file2" ovf:size="2048"/>
+  </References>
+</Envelope>`
+
+	files, err := ovfFileReferences(descriptor)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := []string{"appliance-disk1.vmdk", "appliance-disk2.vmdk"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected %q, got %q", expected, files)
+	}
+
+	if _, err := ovfFileReferences("<Envelope>"); err == nil {
+		t.Fatal("broken descriptor should be an error")
+	}
+}
@@ -0,0 +1,86 @@
+package vsphere
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+// setCustomAttributes sets custom attributes of a managed entity by their
+// names. The attributes removed from old are cleared.
+func setCustomAttributes(c *govmomi.Client, ref types.ManagedObjectReference, old, new map[string]interface{}) error {
+	m, err := object.GetCustomFieldsManager(c.Client)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	for k := range old {
+		values[k] = ""
+	}
+	for k, v := range new {
+		values[k] = v.(string)
+	}
+
+	for _, name := range sortedKeys(values) {
+		key, err := m.FindKey(context.TODO(), name)
+		if err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] setting custom attribute %s of %s", name, ref)
+		if err := m.Set(context.TODO(), ref, key, values[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCustomAttributes gets the custom attributes of a managed entity with
+// non-empty values. Only the attributes in names are returned, so the
+// attributes managed by others don't cause a diff.
+func getCustomAttributes(c *govmomi.Client, ref types.ManagedObjectReference, names map[string]interface{}) (map[string]string, error) {
+	var me mo.ManagedEntity
+	collector := property.DefaultCollector(c.Client)
+	if err := collector.RetrieveOne(context.TODO(), ref, []string{"availableField", "customValue"}, &me); err != nil {
+		return nil, err
+	}
+	return customAttributeValues(me, names), nil
+}
+
+// customAttributeValues gets the non-empty values of the custom attributes in names.
+func customAttributeValues(me mo.ManagedEntity, names map[string]interface{}) map[string]string {
+	keys := make(map[int]string)
+	for _, f := range me.AvailableField {
+		if _, ok := names[f.Name]; ok {
+			keys[f.Key] = f.Name
+		}
+	}
+
+	attributes := make(map[string]string)
+	for _, v := range me.CustomValue {
+		s, ok := v.(*types.CustomFieldStringValue)
+		if !ok || s.Value == "" {
+			continue
+		}
+		if name, ok := keys[s.Key]; ok {
+			attributes[name] = s.Value
+		}
+	}
+	return attributes
+}
+
+// applyCustomAttributes sets custom attributes of a managed entity by the
+// changes of the custom_attributes argument.
+func applyCustomAttributes(c *govmomi.Client, ref types.ManagedObjectReference, d *schema.ResourceData) error {
+	if !d.HasChange("custom_attributes") {
+		return nil
+	}
+	old, new := d.GetChange("custom_attributes")
+	return setCustomAttributes(c, ref, old.(map[string]interface{}), new.(map[string]interface{}))
+}
@@ -0,0 +1,75 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+// hostDatastoreSystem gets the HostDatastoreSystem of a host by its managed object ID.
+func hostDatastoreSystem(c *govmomi.Client, hostID string) (*object.HostDatastoreSystem, error) {
+	host := object.NewHostSystem(c.Client, hostSystemReference(hostID))
+	return host.ConfigManager().DatastoreSystem(context.TODO())
+}
+
+// getDatastore gets a datastore with its hosts, file system and capacity.
+func getDatastore(c *govmomi.Client, ref types.ManagedObjectReference) (*mo.Datastore, error) {
+	var mds mo.Datastore
+	ds := object.NewDatastore(c.Client, ref)
+	if err := ds.Properties(context.TODO(), ref, []string{"name", "info", "host", "summary"}, &mds); err != nil {
+		return nil, err
+	}
+	return &mds, nil
+}
+
+// mountedHostIDs returns the managed object IDs of the hosts which mount a datastore.
+func mountedHostIDs(mds *mo.Datastore) []string {
+	var ids []string
+	for _, mount := range mds.Host {
+		if mount.MountInfo.Mounted == nil || *mount.MountInfo.Mounted {
+			ids = append(ids, mount.Key.Value)
+		}
+	}
+	return ids
+}
+
+// setDatastoreCapacity sets the capacity and free_space attributes in megabytes.
+func setDatastoreCapacity(d *schema.ResourceData, mds *mo.Datastore) {
+	d.Set("capacity", int(mds.Summary.Capacity/1024/1024))
+	d.Set("free_space", int(mds.Summary.FreeSpace/1024/1024))
+}
+
+// removeDatastore removes a datastore from hosts. The datastore is deleted
+// when it's removed from the last host.
+func removeDatastore(c *govmomi.Client, ref types.ManagedObjectReference, hostIDs []string) error {
+	for _, id := range hostIDs {
+		hds, err := hostDatastoreSystem(c, id)
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] Removing datastore %s from host %s", ref.Value, id)
+		if err := hds.Remove(context.TODO(), object.NewDatastore(c.Client, ref)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDatastoreByPath finds a datastore by its inventory path, such as
+// "/Datacenter/datastore/nfs-1".
+func findDatastoreByPath(c *govmomi.Client, p string) (types.ManagedObjectReference, error) {
+	ref, err := object.NewSearchIndex(c.Client).FindByInventoryPath(context.TODO(), p)
+	if err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	if ref == nil || ref.Reference().Type != "Datastore" {
+		return types.ManagedObjectReference{}, fmt.Errorf("Datastore %s not found.", p)
+	}
+	return ref.Reference(), nil
+}
@@ -0,0 +1,124 @@
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	guestInfoEncodingBase64     = "base64"
+	guestInfoEncodingGzipBase64 = "gzip+base64"
+)
+
+// createGuestInfo creates guestinfo extra config for the cloud-init VMware
+// datasource and Ignition from the cloud_init and ignition_config arguments.
+func createGuestInfo(cloudInit []interface{}, ignitionConfig string) (map[string]interface{}, error) {
+	guestInfo := make(map[string]interface{})
+
+	if len(cloudInit) > 0 && cloudInit[0] != nil {
+		c := cloudInit[0].(map[string]interface{})
+		encoding := c["encoding"].(string)
+		for _, v := range []struct {
+			argument string
+			key      string
+		}{
+			{"user_data", "guestinfo.userdata"},
+			{"meta_data", "guestinfo.metadata"},
+			{"vendor_data", "guestinfo.vendordata"},
+		} {
+			data := c[v.argument].(string)
+			if data == "" {
+				continue
+			}
+			encoded, err := encodeGuestInfo(data, encoding)
+			if err != nil {
+				return nil, err
+			}
+			guestInfo[v.key] = encoded
+			guestInfo[v.key+".encoding"] = encoding
+		}
+	}
+
+	if ignitionConfig != "" {
+		encoded, err := encodeGuestInfo(ignitionConfig, guestInfoEncodingBase64)
+		if err != nil {
+			return nil, err
+		}
+		guestInfo["guestinfo.ignition.config.data"] = encoded
+		guestInfo["guestinfo.ignition.config.data.encoding"] = guestInfoEncodingBase64
+	}
+
+	return guestInfo, nil
+}
+
+// encodeGuestInfo encodes data with base64, optionally gzipping it first.
+func encodeGuestInfo(data, encoding string) (string, error) {
+	switch encoding {
+	case guestInfoEncodingBase64:
+		return base64.StdEncoding.EncodeToString([]byte(data)), nil
+	case guestInfoEncodingGzipBase64:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write([]byte(data)); err != nil {
+			return "", err
+		}
+		if err := w.Close(); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	}
+	return "", fmt.Errorf("Invalid encoding %q: it must be %q or %q.", encoding, guestInfoEncodingBase64, guestInfoEncodingGzipBase64)
+}
+
+// validateGuestInfoEncoding validates the encoding argument of cloud_init.
+func validateGuestInfoEncoding(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case guestInfoEncodingBase64, guestInfoEncodingGzipBase64:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q or %q", k, guestInfoEncodingBase64, guestInfoEncodingGzipBase64))
+	}
+	return
+}
+
+// validateCloudInitUserData validates cloud-init user data. Cloud config
+// must be valid YAML. Other formats, such as shell scripts and MIME
+// multi-part archives, are passed through.
+func validateCloudInitUserData(v interface{}, k string) (ws []string, errors []error) {
+	data := v.(string)
+	if !strings.HasPrefix(data, "#cloud-config") {
+		return
+	}
+	var out interface{}
+	if err := yaml.Unmarshal([]byte(data), &out); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not valid cloud config: %s", k, err))
+	}
+	return
+}
+
+// validateCloudInitMetaData validates cloud-init meta data, which is YAML or JSON.
+func validateCloudInitMetaData(v interface{}, k string) (ws []string, errors []error) {
+	var out map[string]interface{}
+	if err := yaml.Unmarshal([]byte(v.(string)), &out); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not valid YAML or JSON: %s", k, err))
+	}
+	return
+}
+
+// validateIgnitionConfig validates an Ignition config, which is a JSON object.
+func validateIgnitionConfig(v interface{}, k string) (ws []string, errors []error) {
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(v.(string)), &out); err != nil {
+		errors = append(errors, fmt.Errorf("%q is not valid JSON: %s", k, err))
+		return
+	}
+	if _, ok := out["ignition"]; !ok {
+		errors = append(errors, fmt.Errorf("%q has no \"ignition\" section", k))
+	}
+	return
+}
@@ -0,0 +1,109 @@
+package vsphere
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCreateGuestInfo_cloudInit(t *testing.T) {
+	cloudInit := []interface{}{
+		map[string]interface{}{
+			"user_data":   "#cloud-config\nhostname: foo\n",
+			"meta_data":   "",
+			"vendor_data": "",
+			"encoding":    "gzip+base64",
+		},
+	}
+
+	guestInfo, err := createGuestInfo(cloudInit, "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(guestInfo) != 2 {
+		t.Fatalf("bad: %#v", guestInfo)
+	}
+	if guestInfo["guestinfo.userdata.encoding"] != "gzip+base64" {
+		t.Fatalf("bad: %#v", guestInfo)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(guestInfo["guestinfo.userdata"].(string))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	userData, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(userData) != "#cloud-config\nhostname: foo\n" {
+		t.Fatalf("bad: %q", userData)
+	}
+}
+
+func TestCreateGuestInfo_ignition(t *testing.T) {
+	guestInfo, err := createGuestInfo(nil, `{"ignition": {"version": "2.2.0"}}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if guestInfo["guestinfo.ignition.config.data"] != "eyJpZ25pdGlvbiI6IHsidmVyc2lvbiI6ICIyLjIuMCJ9fQ==" {
+		t.Fatalf("bad: %#v", guestInfo)
+	}
+	if guestInfo["guestinfo.ignition.config.data.encoding"] != "base64" {
+		t.Fatalf("bad: %#v", guestInfo)
+	}
+}
+
+func TestValidateCloudInitUserData(t *testing.T) {
+	validValues := []string{
+		"#cloud-config\npackages:\n  - nginx\n",
+		"#!/bin/sh\necho hello: [\n",
+	}
+	for _, v := range validValues {
+		if _, errors := validateCloudInitUserData(v, "user_data"); len(errors) != 0 {
+			t.Fatalf("%q should be valid: %q", v, errors)
+		}
+	}
+
+	invalidValues := []string{
+		"#cloud-config\npackages: [nginx\n",
+	}
+	for _, v := range invalidValues {
+		if _, errors := validateCloudInitUserData(v, "user_data"); len(errors) == 0 {
+			t.Fatalf("%q should be invalid", v)
+		}
+	}
+}
+
+func TestValidateCloudInitMetaData(t *testing.T) {
+	validValues := []string{
+		"instance-id: foo\nlocal-hostname: foo\n",
+		`{"instance-id": "foo"}`,
+	}
+	for _, v := range validValues {
+		if _, errors := validateCloudInitMetaData(v, "meta_data"); len(errors) != 0 {
+			t.Fatalf("%q should be valid: %q", v, errors)
+		}
+	}
+
+	if _, errors := validateCloudInitMetaData("- foo\n- bar\n", "meta_data"); len(errors) == 0 {
+		t.Fatal("a list should be invalid")
+	}
+}
+
+func TestValidateIgnitionConfig(t *testing.T) {
+	if _, errors := validateIgnitionConfig(`{"ignition": {"version": "2.2.0"}}`, "ignition_config"); len(errors) != 0 {
+		t.Fatalf("should be valid: %q", errors)
+	}
+	if _, errors := validateIgnitionConfig(`{"ignition": `, "ignition_config"); len(errors) == 0 {
+		t.Fatal("broken JSON should be invalid")
+	}
+	if _, errors := validateIgnitionConfig(`{"storage": {}}`, "ignition_config"); len(errors) == 0 {
+		t.Fatal("config without ignition section should be invalid")
+	}
+}
@@ -0,0 +1,70 @@
+package vsphere
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+// inventoryPath gets the inventory path of a managed entity, such as
+// "/Datacenter/vm/prod/web".
+func inventoryPath(c *govmomi.Client, ref types.ManagedObjectReference) (string, error) {
+	entities, err := mo.Ancestors(context.TODO(), c.Client, c.ServiceContent.PropertyCollector, ref)
+	if err != nil {
+		return "", err
+	}
+
+	p := "/"
+	for _, e := range entities {
+		// The root folder is not a part of inventory paths.
+		if e.Parent == nil {
+			continue
+		}
+		p = path.Join(p, e.Name)
+	}
+	return p, nil
+}
+
+// getEntityDatacenter gets the datacenter of a managed entity.
+func getEntityDatacenter(c *govmomi.Client, ref types.ManagedObjectReference) (*object.Datacenter, error) {
+	entities, err := mo.Ancestors(context.TODO(), c.Client, c.ServiceContent.PropertyCollector, ref)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entities {
+		if e.Self.Type == "Datacenter" {
+			return object.NewDatacenter(c.Client, e.Self), nil
+		}
+	}
+	return nil, fmt.Errorf("No datacenter found for %s %s.", ref.Type, ref.Value)
+}
+
+// isManagedObjectNotFound returns true if err is a ManagedObjectNotFound fault,
+// which means that the managed object was deleted.
+func isManagedObjectNotFound(err error) bool {
+	if !soap.IsSoapFault(err) {
+		return false
+	}
+	_, ok := soap.ToSoapFault(err).VimFault().(types.ManagedObjectNotFound)
+	return ok
+}
+
+// renameEntity renames a managed entity.
+func renameEntity(c *govmomi.Client, ref types.ManagedObjectReference, name string) error {
+	req := types.Rename_Task{
+		This:    ref,
+		NewName: name,
+	}
+	res, err := methods.Rename_Task(context.TODO(), c.Client, &req)
+	if err != nil {
+		return err
+	}
+	return object.NewTask(c.Client, res.Returnval).Wait(context.TODO())
+}
@@ -0,0 +1,572 @@
+package vsphere
+
+import (
+	"archive/tar"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	ovfDiskProvisioningThin             = "thin"
+	ovfDiskProvisioningThick            = "thick"
+	ovfDiskProvisioningEagerZeroedThick = "eagerZeroedThick"
+
+	ovfLeaseProgressInterval = 10 * time.Second
+)
+
+type ovfSource struct {
+	localPath          string
+	remoteURL          string
+	networkMap         map[string]string
+	diskProvisioning   string
+	allowUnverifiedSSL bool
+}
+
+// ovfArchive reads the descriptor and the files referenced by it from an OVF package.
+type ovfArchive interface {
+	// descriptor returns the OVF descriptor.
+	descriptor() (string, error)
+	// open opens a file referenced by the descriptor and returns its size.
+	open(name string) (io.ReadCloser, int64, error)
+}
+
+// getOvfSource gets the ovf_source block.
+func getOvfSource(d *schema.ResourceData) (*ovfSource, error) {
+	v, ok := d.GetOk("ovf_source")
+	if !ok {
+		return nil, nil
+	}
+	s := v.([]interface{})[0].(map[string]interface{})
+	source := &ovfSource{
+		localPath:          s["local_path"].(string),
+		remoteURL:          s["remote_url"].(string),
+		networkMap:         make(map[string]string),
+		diskProvisioning:   s["disk_provisioning"].(string),
+		allowUnverifiedSSL: s["allow_unverified_ssl"].(bool),
+	}
+	if (source.localPath == "") == (source.remoteURL == "") {
+		return nil, fmt.Errorf("Either local_path or remote_url must be specified in ovf_source.")
+	}
+	for k, v := range s["network_map"].(map[string]interface{}) {
+		source.networkMap[k] = v.(string)
+	}
+	return source, nil
+}
+
+// validateOvfDiskProvisioning validates the disk_provisioning argument of ovf_source.
+func validateOvfDiskProvisioning(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case ovfDiskProvisioningThin, ovfDiskProvisioningThick, ovfDiskProvisioningEagerZeroedThick:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q or %q", k, ovfDiskProvisioningThin, ovfDiskProvisioningThick, ovfDiskProvisioningEagerZeroedThick))
+	}
+	return
+}
+
+// newOvfArchive creates ovfArchive for the OVF or OVA file of the ovf_source.
+func (s *ovfSource) newOvfArchive() (ovfArchive, error) {
+	if s.localPath != "" {
+		switch strings.ToLower(filepath.Ext(s.localPath)) {
+		case ".ova":
+			return &ovaArchive{
+				openFile: func() (io.ReadCloser, error) {
+					return os.Open(s.localPath)
+				},
+			}, nil
+		case ".ovf":
+			return &localOvfArchive{path: s.localPath}, nil
+		}
+		return nil, fmt.Errorf("Unsupported file %s: local_path must be an .ova or .ovf file.", s.localPath)
+	}
+
+	u, err := url.Parse(s.remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	client := newDownloadClient(s.allowUnverifiedSSL)
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".ova":
+		return &ovaArchive{
+			openFile: func() (io.ReadCloser, error) {
+				body, _, err := httpGet(client, u)
+				return body, err
+			},
+		}, nil
+	case ".ovf":
+		return &remoteOvfArchive{client: client, url: u}, nil
+	}
+	return nil, fmt.Errorf("Unsupported URL %s: remote_url must point to an .ova or .ovf file.", s.remoteURL)
+}
+
+// localOvfArchive is an OVF descriptor with its files in the same local directory.
+type localOvfArchive struct {
+	path string
+}
+
+func (a *localOvfArchive) descriptor() (string, error) {
+	b, err := ioutil.ReadFile(a.path)
+	return string(b), err
+}
+
+func (a *localOvfArchive) open(name string) (io.ReadCloser, int64, error) {
+	return openLocalFile(filepath.Join(filepath.Dir(a.path), name))
+}
+
+// remoteOvfArchive is an OVF descriptor with its files under the same URL.
+type remoteOvfArchive struct {
+	client *http.Client
+	url    *url.URL
+}
+
+func (a *remoteOvfArchive) descriptor() (string, error) {
+	body, _, err := httpGet(a.client, a.url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	return string(b), err
+}
+
+func (a *remoteOvfArchive) open(name string) (io.ReadCloser, int64, error) {
+	ref, err := url.Parse(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return httpGet(a.client, a.url.ResolveReference(ref))
+}
+
+// ovaArchive is an OVA file, which is a tar archive of an OVF package.
+// The archive is read from the beginning every time a file is opened.
+type ovaArchive struct {
+	openFile func() (io.ReadCloser, error)
+}
+
+// tarEntry is a file in a tar archive, which closes the archive when it's closed.
+type tarEntry struct {
+	io.Reader
+	io.Closer
+}
+
+func (a *ovaArchive) find(match func(name string) bool) (io.ReadCloser, int64, error) {
+	f, err := a.openFile()
+	if err != nil {
+		return nil, 0, err
+	}
+	r := tar.NewReader(f)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		if match(path.Clean(h.Name)) {
+			return tarEntry{r, f}, h.Size, nil
+		}
+	}
+	f.Close()
+	return nil, 0, os.ErrNotExist
+}
+
+func (a *ovaArchive) descriptor() (string, error) {
+	r, _, err := a.find(func(name string) bool {
+		return strings.ToLower(path.Ext(name)) == ".ovf"
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to find OVF descriptor in OVA: %s", err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	return string(b), err
+}
+
+func (a *ovaArchive) open(name string) (io.ReadCloser, int64, error) {
+	name = path.Clean(name)
+	r, size, err := a.find(func(n string) bool {
+		return n == name
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to find %s in OVA: %s", name, err)
+	}
+	return r, size, nil
+}
+
+// openLocalFile opens a local file and returns its size.
+func openLocalFile(name string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, fi.Size(), nil
+}
+
+// newDownloadClient creates an HTTP client to download OVF packages and other files.
+func newDownloadClient(allowUnverifiedSSL bool) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: allowUnverifiedSSL},
+		},
+	}
+}
+
+// httpGet gets the content of u and returns its body and size.
+func httpGet(client *http.Client, u *url.URL) (io.ReadCloser, int64, error) {
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("Failed to download %s: %s", u, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// createOvfNetworkMapping maps the networks of the OVF descriptor to networks
+// in the datacenter. A network not in networkMap is mapped to the label of the
+// network_interface in the same position.
+func createOvfNetworkMapping(c *govmomi.Client, f *find.Finder, networks []types.OvfNetworkInfo, networkMap map[string]string, nics []networkInterface) ([]types.OvfNetworkMapping, error) {
+	var mapping []types.OvfNetworkMapping
+	for i, n := range networks {
+		label, ok := networkMap[n.Name]
+		if !ok {
+			if i >= len(nics) {
+				return nil, fmt.Errorf("OVF network %s is not mapped: add it to network_map.", n.Name)
+			}
+			label = nics[i].label
+		}
+		network, err := findNetwork(c, f, label)
+		if err != nil {
+			return nil, err
+		}
+		mapping = append(mapping, types.OvfNetworkMapping{
+			Name:    n.Name,
+			Network: network.Reference(),
+		})
+	}
+	log.Printf("[DEBUG] OVF network mapping: %#v", mapping)
+	return mapping, nil
+}
+
+// createOvfPropertyMapping creates property mapping for the vApp properties
+// defined in the OVF descriptor.
+func createOvfPropertyMapping(defined []types.VAppPropertyInfo, properties map[string]string) ([]types.KeyValue, error) {
+	valid := make([]string, 0, len(defined))
+	for _, p := range defined {
+		valid = append(valid, p.Id)
+	}
+	if err := validateVAppPropertyKeys(properties, valid); err != nil {
+		return nil, err
+	}
+
+	var mapping []types.KeyValue
+	for _, k := range sortedKeys(properties) {
+		mapping = append(mapping, types.KeyValue{
+			Key:   k,
+			Value: properties[k],
+		})
+	}
+	return mapping, nil
+}
+
+// ovfErrors joins the messages of the errors in an OVF result.
+func ovfErrors(faults []types.LocalizedMethodFault) error {
+	messages := make([]string, 0, len(faults))
+	for _, f := range faults {
+		messages = append(messages, f.LocalizedMessage)
+	}
+	return fmt.Errorf("Invalid OVF descriptor: %s", strings.Join(messages, "; "))
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	io.Reader
+	n *int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
+// uploadOvfFiles uploads the files of the OVF package to the device URLs of the lease.
+// The progress of the lease is updated periodically so that it does not time out.
+func uploadOvfFiles(c *govmomi.Client, lease *object.HttpNfcLease, info *types.HttpNfcLeaseInfo, archive ovfArchive, items []types.OvfFileItem) error {
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+
+	var uploaded int64
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(ovfLeaseProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				percent := 0
+				if total > 0 {
+					percent = int(atomic.LoadInt64(&uploaded) * 100 / total)
+				}
+				if percent > 99 {
+					percent = 99
+				}
+				if err := lease.HttpNfcLeaseProgress(context.TODO(), percent); err != nil {
+					log.Printf("[WARN] Failed to update lease progress: %s", err)
+				}
+			}
+		}
+	}()
+
+	for _, item := range items {
+		var deviceURL *types.HttpNfcLeaseDeviceUrl
+		for i := range info.DeviceUrl {
+			if info.DeviceUrl[i].ImportKey == item.DeviceId {
+				deviceURL = &info.DeviceUrl[i]
+				break
+			}
+		}
+		if deviceURL == nil {
+			return fmt.Errorf("No device URL found for %s.", item.Path)
+		}
+
+		u, err := c.Client.ParseURL(deviceURL.Url)
+		if err != nil {
+			return err
+		}
+
+		r, size, err := archive.open(item.Path)
+		if err != nil {
+			return err
+		}
+		if size < 0 {
+			size = item.Size
+		}
+
+		upload := soap.Upload{
+			Type:          "application/x-vnd.vmware-streamVmdk",
+			Method:        "POST",
+			ContentLength: size,
+		}
+		if item.Create {
+			upload.Method = "PUT"
+		}
+		log.Printf("[DEBUG] uploading %s (%d bytes) to %s", item.Path, size, u)
+		err = c.Client.Upload(countingReader{r, &uploaded}, u, &upload)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findImportDatastore finds the datastore to import the VirtualMachine into.
+func (vm *virtualMachine) findImportDatastore(c *govmomi.Client, dc *object.Datacenter, finder *find.Finder) (*object.Datastore, error) {
+	if vm.datastore == "" {
+		return finder.DefaultDatastore(context.TODO())
+	}
+	datastore, err := finder.Datastore(context.TODO(), vm.datastore)
+	if err == nil {
+		return datastore, nil
+	}
+
+	// The finder doesn't find datastore clusters.
+	if _, ok := err.(*find.NotFoundError); ok {
+		dcFolders, ferr := dc.Folders(context.TODO())
+		if ferr != nil {
+			return nil, ferr
+		}
+		if ref, ferr := getDatastoreObject(c, dcFolders, vm.datastore); ferr == nil && ref.Type == "StoragePod" {
+			return nil, fmt.Errorf("Importing OVF into datastore cluster %s is not supported.", vm.datastore)
+		}
+	}
+	return nil, err
+}
+
+// importVirtualMachine imports a new VirtualMachine from an OVF or OVA file.
+func (vm *virtualMachine) importVirtualMachine(c *govmomi.Client) error {
+	dc, err := getDatacenter(c, vm.datacenter)
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(c.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	resourcePool, err := vm.findResourcePool(finder)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] resource pool: %#v", resourcePool)
+
+	folder, err := getVMFolder(c, dc, vm.folder)
+	if err != nil {
+		return err
+	}
+
+	datastore, err := vm.findImportDatastore(c, dc, finder)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] datastore: %#v", datastore)
+
+	host, err := vm.findHost(finder)
+	if err != nil {
+		return err
+	}
+
+	archive, err := vm.ovfSource.newOvfArchive()
+	if err != nil {
+		return err
+	}
+	descriptor, err := archive.descriptor()
+	if err != nil {
+		return err
+	}
+
+	m := object.NewOvfManager(c.Client)
+	parsed, err := m.ParseDescriptor(context.TODO(), descriptor, types.OvfParseDescriptorParams{})
+	if err != nil {
+		return err
+	}
+	if len(parsed.Error) > 0 {
+		return ovfErrors(parsed.Error)
+	}
+
+	networkMapping, err := createOvfNetworkMapping(c, finder, parsed.Network, vm.ovfSource.networkMap, vm.networkInterfaces)
+	if err != nil {
+		return err
+	}
+	propertyMapping, err := createOvfPropertyMapping(parsed.Property, vm.vAppProperties)
+	if err != nil {
+		return err
+	}
+
+	cisp := types.OvfCreateImportSpecParams{
+		EntityName:       vm.name,
+		NetworkMapping:   networkMapping,
+		PropertyMapping:  propertyMapping,
+		DiskProvisioning: vm.ovfSource.diskProvisioning,
+	}
+	spec, err := m.CreateImportSpec(context.TODO(), descriptor, resourcePool, datastore, cisp)
+	if err != nil {
+		return err
+	}
+	if len(spec.Error) > 0 {
+		return ovfErrors(spec.Error)
+	}
+	for _, w := range spec.Warning {
+		log.Printf("[WARN] OVF import: %s", w.LocalizedMessage)
+	}
+
+	lease, err := resourcePool.ImportVApp(context.TODO(), spec.ImportSpec, folder, host)
+	if err != nil {
+		return err
+	}
+	info, err := lease.Wait(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	if err := uploadOvfFiles(c, lease, info, archive, spec.FileItem); err != nil {
+		if e := lease.HttpNfcLeaseAbort(context.TODO(), nil); e != nil {
+			log.Printf("[ERROR] Failed to abort lease: %s", e)
+		}
+		return err
+	}
+	if err := lease.HttpNfcLeaseComplete(context.TODO()); err != nil {
+		return err
+	}
+	vm.created = true
+
+	newVM := object.NewVirtualMachine(c.Client, info.Entity)
+	log.Printf("[DEBUG] new vm: %v", newVM)
+
+	return vm.finishImport(c, newVM, vm.ovfSource.diskProvisioning)
+}
+
+// finishImport applies the settings to a VirtualMachine imported from an OVF
+// package and powers it on.
+func (vm *virtualMachine) finishImport(c *govmomi.Client, newVM *object.VirtualMachine, diskProvisioning string) error {
+	configSpec := vm.createConfigSpec()
+	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
+	task, err := newVM.Reconfigure(context.TODO(), configSpec)
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	// The root disk is imported from the OVF, so only its I/O settings are applied.
+	err = setHardDiskIO(newVM, 0, vm.hardDisks[0])
+	if err != nil {
+		return err
+	}
+
+	diskType := "thin"
+	if diskProvisioning == ovfDiskProvisioningEagerZeroedThick {
+		diskType = "eager_zeroed"
+	}
+	err = addHardDisks(c, newVM, vm.hardDisks[1:], nil, diskType)
+	if err != nil {
+		return err
+	}
+
+	// Appliances usually configure themselves from vApp properties, so guest
+	// customization is applied only when a static IP address is requested.
+	customize := false
+	for _, network := range vm.networkInterfaces {
+		if network.ipAddress != "" {
+			customize = true
+		}
+	}
+	if customize && len(vm.guestInfo) == 0 {
+		customSpec, err := vm.createCustomizationSpec()
+		if err != nil {
+			return err
+		}
+		task, err := newVM.Customize(context.TODO(), *customSpec)
+		if err := waitForTask(task, err); err != nil {
+			return err
+		}
+	}
+
+	if vm.powerState != powerStateOff {
+		err = changePowerState(newVM, powerStateOn, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
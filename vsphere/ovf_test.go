@@ -0,0 +1,87 @@
+package vsphere
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestOvaArchive(t *testing.T) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		body string
+	}{
+		{"appliance.ovf", "<Envelope/>"},
+		{"appliance.mf", "SHA1(appliance.ovf)= 0"},
+		{"appliance-disk1.vmdk", "disk"},
+	} {
+		if err := w.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.body))}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := w.Write([]byte(f.body)); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	archive := &ovaArchive{
+		openFile: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		},
+	}
+
+	descriptor, err := archive.descriptor()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if descriptor != "<Envelope/>" {
+		t.Fatalf("bad: %q", descriptor)
+	}
+
+	r, size, err := archive.open("./appliance-disk1.vmdk")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(b) != "disk" || size != 4 {
+		t.Fatalf("bad: %q (%d bytes)", b, size)
+	}
+
+	if _, _, err := archive.open("appliance-disk2.vmdk"); err == nil {
+		t.Fatal("missing file should be an error")
+	}
+}
+
+func TestCreateOvfPropertyMapping(t *testing.T) {
+	defined := []types.VAppPropertyInfo{
+		{Id: "hostname"},
+		{Id: "ip0"},
+	}
+
+	mapping, err := createOvfPropertyMapping(defined, map[string]string{
+		"ip0":      "10.0.0.10",
+		"hostname": "appliance",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(mapping) != 2 || mapping[0].Key != "hostname" || mapping[1].Value != "10.0.0.10" {
+		t.Fatalf("bad: %#v", mapping)
+	}
+
+	if _, err := createOvfPropertyMapping(defined, map[string]string{"password": "x"}); err == nil {
+		t.Fatal("unknown property should be an error")
+	}
+}
@@ -29,10 +29,41 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("VSPHERE_VCENTER", nil),
 				Description: "The vCenter Server name for vSphere API operations.",
 			},
+
+			"default_dns_servers": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The DNS servers used by virtual machines without dns_server.",
+			},
+
+			"default_dns_suffixes": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The DNS suffixes used by virtual machines without dns_suffix.",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"vsphere_virtual_machine": resourceVSphereVirtualMachine(),
+			"vsphere_compute_cluster":                           resourceVSphereComputeCluster(),
+			"vsphere_compute_cluster_host_group":                resourceVSphereComputeClusterHostGroup(),
+			"vsphere_compute_cluster_vm_affinity_rule":          resourceVSphereComputeClusterVMAffinityRule(),
+			"vsphere_compute_cluster_vm_anti_affinity_rule":     resourceVSphereComputeClusterVMAntiAffinityRule(),
+			"vsphere_compute_cluster_vm_group":                  resourceVSphereComputeClusterVMGroup(),
+			"vsphere_compute_cluster_vm_host_rule":              resourceVSphereComputeClusterVMHostRule(),
+			"vsphere_content_library":                           resourceVSphereContentLibrary(),
+			"vsphere_content_library_item":                      resourceVSphereContentLibraryItem(),
+			"vsphere_datacenter":                                resourceVSphereDatacenter(),
+			"vsphere_datastore_cluster":                         resourceVSphereDatastoreCluster(),
+			"vsphere_datastore_cluster_vmdk_anti_affinity_rule": resourceVSphereDatastoreClusterVMDKAntiAffinityRule(),
+			"vsphere_folder":                                    resourceVSphereFolder(),
+			"vsphere_host":                                      resourceVSphereHost(),
+			"vsphere_nas_datastore":                             resourceVSphereNasDatastore(),
+			"vsphere_resource_pool":                             resourceVSphereResourcePool(),
+			"vsphere_storage_drs_vm_override":                   resourceVSphereStorageDrsVMOverride(),
+			"vsphere_virtual_machine":                           resourceVSphereVirtualMachine(),
+			"vsphere_vmfs_datastore":                            resourceVSphereVmfsDatastore(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -46,5 +77,13 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		VCenterServer: d.Get("vcenter_server").(string),
 	}
 
+	for _, v := range d.Get("default_dns_servers").([]interface{}) {
+		config.DefaultDNSServers = append(config.DefaultDNSServers, v.(string))
+	}
+
+	for _, v := range d.Get("default_dns_suffixes").([]interface{}) {
+		config.DefaultDNSSuffixes = append(config.DefaultDNSSuffixes, v.(string))
+	}
+
 	return config.Client()
 }
@@ -0,0 +1,73 @@
+package vsphere
+
+import (
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// The govmomi types omit a reservation of 0 from requests, so a reservation
+// could never be reset to 0 once set. The types below always send it. They're
+// named after the vSphere types because the name of the Go type is sent as the
+// xsi:type of the value.
+
+// ResourceAllocationInfo is types.ResourceAllocationInfo which always sends Reservation.
+type ResourceAllocationInfo struct {
+	types.DynamicData
+
+	Reservation           int64             `xml:"reservation"`
+	ExpandableReservation *bool             `xml:"expandableReservation"`
+	Limit                 int64             `xml:"limit,omitempty"`
+	Shares                *types.SharesInfo `xml:"shares,omitempty"`
+	OverheadLimit         int64             `xml:"overheadLimit,omitempty"`
+}
+
+// GetResourceAllocationInfo implements types.BaseResourceAllocationInfo.
+func (a *ResourceAllocationInfo) GetResourceAllocationInfo() *types.ResourceAllocationInfo {
+	return &types.ResourceAllocationInfo{
+		DynamicData:           a.DynamicData,
+		Reservation:           a.Reservation,
+		ExpandableReservation: a.ExpandableReservation,
+		Limit:                 a.Limit,
+		Shares:                a.Shares,
+		OverheadLimit:         a.OverheadLimit,
+	}
+}
+
+// StorageIOAllocationInfo is types.StorageIOAllocationInfo which always sends Reservation.
+type StorageIOAllocationInfo struct {
+	types.DynamicData
+
+	Limit       int64             `xml:"limit,omitempty"`
+	Shares      *types.SharesInfo `xml:"shares,omitempty"`
+	Reservation int               `xml:"reservation"`
+}
+
+// VirtualDisk is types.VirtualDisk with StorageIOAllocationInfo.
+type VirtualDisk struct {
+	types.VirtualDevice
+
+	CapacityInKB          int64                                   `xml:"capacityInKB"`
+	CapacityInBytes       int64                                   `xml:"capacityInBytes,omitempty"`
+	Shares                *types.SharesInfo                       `xml:"shares,omitempty"`
+	StorageIOAllocation   *StorageIOAllocationInfo                `xml:"storageIOAllocation,omitempty"`
+	DiskObjectId          string                                  `xml:"diskObjectId,omitempty"`
+	VFlashCacheConfigInfo *types.VirtualDiskVFlashCacheConfigInfo `xml:"vFlashCacheConfigInfo,omitempty"`
+	Iofilter              []string                                `xml:"iofilter,omitempty"`
+}
+
+// newVirtualDisk creates VirtualDisk from the disk with the I/O allocation.
+func newVirtualDisk(disk *types.VirtualDisk, allocation *types.StorageIOAllocationInfo) *VirtualDisk {
+	return &VirtualDisk{
+		VirtualDevice:   disk.VirtualDevice,
+		CapacityInKB:    disk.CapacityInKB,
+		CapacityInBytes: disk.CapacityInBytes,
+		Shares:          disk.Shares,
+		StorageIOAllocation: &StorageIOAllocationInfo{
+			Limit:       allocation.Limit,
+			Shares:      allocation.Shares,
+			Reservation: allocation.Reservation,
+		},
+		DiskObjectId:          disk.DiskObjectId,
+		VFlashCacheConfigInfo: disk.VFlashCacheConfigInfo,
+		Iofilter:              disk.Iofilter,
+	}
+}
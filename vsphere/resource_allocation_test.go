@@ -0,0 +1,48 @@
+package vsphere
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+func TestResourceAllocationInfoXML(t *testing.T) {
+	spec := types.VirtualMachineConfigSpec{
+		CpuAllocation: &ResourceAllocationInfo{Limit: -1},
+	}
+	b, err := xml.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if !strings.Contains(s, `type="ResourceAllocationInfo"`) {
+		t.Fatalf("cpuAllocation should be sent as ResourceAllocationInfo: %s", s)
+	}
+	if !strings.Contains(s, "<reservation>0</reservation>") {
+		t.Fatalf("a reservation of 0 should be sent: %s", s)
+	}
+}
+
+func TestVirtualDiskXML(t *testing.T) {
+	disk := &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{Key: 2000},
+		CapacityInKB:  1024,
+	}
+	spec := types.VirtualDeviceConfigSpec{
+		Operation: types.VirtualDeviceConfigSpecOperationEdit,
+		Device:    newVirtualDisk(disk, &types.StorageIOAllocationInfo{Limit: -1}),
+	}
+	b, err := xml.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if !strings.Contains(s, `type="VirtualDisk"`) || !strings.Contains(s, "<key>2000</key>") {
+		t.Fatalf("device should be sent as VirtualDisk: %s", s)
+	}
+	if !strings.Contains(s, "<storageIOAllocation><limit>-1</limit><reservation>0</reservation></storageIOAllocation>") {
+		t.Fatalf("an I/O reservation of 0 should be sent: %s", s)
+	}
+}
@@ -0,0 +1,493 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	haAdmissionControlPolicyResourcePercentage = "resourcePercentage"
+	haAdmissionControlPolicySlotPolicy         = "slotPolicy"
+	haAdmissionControlPolicyFailoverHosts      = "failoverHosts"
+	haAdmissionControlPolicyDisabled           = "disabled"
+)
+
+func resourceVSphereComputeCluster() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereComputeClusterCreate,
+		Read:   resourceVSphereComputeClusterRead,
+		Update: resourceVSphereComputeClusterUpdate,
+		Delete: resourceVSphereComputeClusterDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereComputeClusterImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"drs_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"drs_automation_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.DrsBehaviorManual),
+				ValidateFunc: validateDrsBehavior,
+			},
+
+			"drs_migration_threshold": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validateClusterThreshold,
+			},
+
+			"ha_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"ha_host_monitoring": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"ha_admission_control_policy": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      haAdmissionControlPolicyResourcePercentage,
+				ValidateFunc: validateHAAdmissionControlPolicy,
+			},
+
+			"ha_admission_control_host_failure_tolerance": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  1,
+			},
+
+			"ha_admission_control_cpu_failover_percent": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  25,
+			},
+
+			"ha_admission_control_memory_failover_percent": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  25,
+			},
+
+			"ha_admission_control_failover_host_ids": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"ha_vm_restart_priority": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.ClusterDasVmSettingsRestartPriorityMedium),
+				ValidateFunc: validateHAVMRestartPriority,
+			},
+
+			"ha_host_isolation_response": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.ClusterDasVmSettingsIsolationResponseNone),
+				ValidateFunc: validateHAIsolationResponse,
+			},
+
+			"evc_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"dpm_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"dpm_automation_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.DpmBehaviorManual),
+				ValidateFunc: validateDpmBehavior,
+			},
+
+			"dpm_threshold": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validateClusterThreshold,
+			},
+
+			"resource_pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVSphereComputeClusterCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	folder, err := getRootFolder(client, d.Get("datacenter").(string), folderTypeHost)
+	if err != nil {
+		return err
+	}
+
+	spec, err := createClusterConfigSpec(d)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] cluster config spec: %#v", spec)
+
+	cluster, err := folder.CreateCluster(context.TODO(), d.Get("name").(string), *spec)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(cluster.Reference().Value)
+	log.Printf("[INFO] Created cluster: %s", d.Id())
+
+	if evcMode := d.Get("evc_mode").(string); evcMode != "" {
+		if err := configureEvcMode(client, cluster.Reference(), evcMode); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereComputeClusterRead(d, meta)
+}
+
+func resourceVSphereComputeClusterRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := object.NewClusterComputeResource(client.Client, clusterReference(d.Id()))
+
+	var mcc mo.ClusterComputeResource
+	if err := cluster.Properties(context.TODO(), cluster.Reference(), []string{"name", "configurationEx", "summary", "resourcePool"}, &mcc); err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Cluster not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", mcc.Name)
+	if mcc.ResourcePool != nil {
+		d.Set("resource_pool_id", mcc.ResourcePool.Value)
+	}
+	if summary, ok := mcc.Summary.(*types.ClusterComputeResourceSummary); ok {
+		d.Set("evc_mode", summary.CurrentEVCModeKey)
+	}
+
+	config, ok := mcc.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return fmt.Errorf("Unexpected configuration of cluster %s: %#v", d.Id(), mcc.ConfigurationEx)
+	}
+
+	drs := config.DrsConfig
+	d.Set("drs_enabled", drs.Enabled != nil && *drs.Enabled)
+	d.Set("drs_automation_level", string(drs.DefaultVmBehavior))
+	if drs.VmotionRate != 0 {
+		d.Set("drs_migration_threshold", 6-drs.VmotionRate)
+	}
+
+	das := config.DasConfig
+	d.Set("ha_enabled", das.Enabled != nil && *das.Enabled)
+	d.Set("ha_host_monitoring", das.HostMonitoring != string(types.ClusterDasConfigInfoServiceStateDisabled))
+	if das.AdmissionControlEnabled != nil && !*das.AdmissionControlEnabled {
+		d.Set("ha_admission_control_policy", haAdmissionControlPolicyDisabled)
+	} else {
+		switch p := das.AdmissionControlPolicy.(type) {
+		case *types.ClusterFailoverResourcesAdmissionControlPolicy:
+			d.Set("ha_admission_control_policy", haAdmissionControlPolicyResourcePercentage)
+			d.Set("ha_admission_control_cpu_failover_percent", p.CpuFailoverResourcesPercent)
+			d.Set("ha_admission_control_memory_failover_percent", p.MemoryFailoverResourcesPercent)
+		case *types.ClusterFailoverLevelAdmissionControlPolicy:
+			d.Set("ha_admission_control_policy", haAdmissionControlPolicySlotPolicy)
+			d.Set("ha_admission_control_host_failure_tolerance", p.FailoverLevel)
+		case *types.ClusterFailoverHostAdmissionControlPolicy:
+			d.Set("ha_admission_control_policy", haAdmissionControlPolicyFailoverHosts)
+			var ids []string
+			for _, ref := range p.FailoverHosts {
+				ids = append(ids, ref.Value)
+			}
+			d.Set("ha_admission_control_failover_host_ids", ids)
+		}
+	}
+	if das.DefaultVmSettings != nil {
+		d.Set("ha_vm_restart_priority", das.DefaultVmSettings.RestartPriority)
+		d.Set("ha_host_isolation_response", das.DefaultVmSettings.IsolationResponse)
+	}
+
+	if dpm := config.DpmConfigInfo; dpm != nil {
+		d.Set("dpm_enabled", dpm.Enabled != nil && *dpm.Enabled)
+		d.Set("dpm_automation_level", string(dpm.DefaultDpmBehavior))
+		if dpm.HostPowerActionRate != 0 {
+			d.Set("dpm_threshold", 6-dpm.HostPowerActionRate)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereComputeClusterUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := object.NewComputeResource(client.Client, clusterReference(d.Id()))
+
+	if d.HasChange("name") {
+		log.Printf("[INFO] Renaming cluster %s to %s", d.Id(), d.Get("name").(string))
+		if err := renameEntity(client, cluster.Reference(), d.Get("name").(string)); err != nil {
+			return err
+		}
+	}
+
+	spec, err := createClusterConfigSpec(d)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] cluster config spec: %#v", spec)
+
+	task, err := cluster.Reconfigure(context.TODO(), spec, true)
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	if d.HasChange("evc_mode") {
+		if err := configureEvcMode(client, cluster.Reference(), d.Get("evc_mode").(string)); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereComputeClusterRead(d, meta)
+}
+
+func resourceVSphereComputeClusterDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := object.NewClusterComputeResource(client.Client, clusterReference(d.Id()))
+
+	log.Printf("[INFO] Deleting cluster: %s", d.Id())
+	task, err := cluster.Destroy(context.TODO())
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereComputeClusterImport imports a cluster by its inventory
+// path, such as "/Datacenter/host/Cluster".
+func resourceVSphereComputeClusterImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	p := d.Id()
+	i := strings.Index(p, "/host/")
+	if !strings.HasPrefix(p, "/") || i < 1 {
+		return nil, fmt.Errorf("Invalid cluster path %q: it must be like /<datacenter>/host/<cluster>.", p)
+	}
+
+	finder := find.NewFinder(client.Client, true)
+	cluster, err := finder.ClusterComputeResource(context.TODO(), p)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(cluster.Reference().Value)
+	d.Set("datacenter", p[1:i])
+	return []*schema.ResourceData{d}, nil
+}
+
+// clusterReference returns the reference of a cluster by its managed object ID.
+func clusterReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "ClusterComputeResource",
+		Value: id,
+	}
+}
+
+// createClusterConfigSpec creates ClusterConfigSpecEx from the DRS, HA and DPM arguments.
+func createClusterConfigSpec(d *schema.ResourceData) (*types.ClusterConfigSpecEx, error) {
+	// The migration threshold of the vSphere Client is the reverse of vmotionRate,
+	// where 1 is the most aggressive.
+	drs := &types.ClusterDrsConfigInfo{
+		Enabled:           types.NewBool(d.Get("drs_enabled").(bool)),
+		DefaultVmBehavior: types.DrsBehavior(d.Get("drs_automation_level").(string)),
+		VmotionRate:       6 - d.Get("drs_migration_threshold").(int),
+	}
+
+	hostMonitoring := types.ClusterDasConfigInfoServiceStateEnabled
+	if !d.Get("ha_host_monitoring").(bool) {
+		hostMonitoring = types.ClusterDasConfigInfoServiceStateDisabled
+	}
+	das := &types.ClusterDasConfigInfo{
+		Enabled:                 types.NewBool(d.Get("ha_enabled").(bool)),
+		HostMonitoring:          string(hostMonitoring),
+		AdmissionControlEnabled: types.NewBool(true),
+		DefaultVmSettings: &types.ClusterDasVmSettings{
+			RestartPriority:   d.Get("ha_vm_restart_priority").(string),
+			IsolationResponse: d.Get("ha_host_isolation_response").(string),
+		},
+	}
+	switch d.Get("ha_admission_control_policy").(string) {
+	case haAdmissionControlPolicyResourcePercentage:
+		das.AdmissionControlPolicy = &types.ClusterFailoverResourcesAdmissionControlPolicy{
+			CpuFailoverResourcesPercent:    d.Get("ha_admission_control_cpu_failover_percent").(int),
+			MemoryFailoverResourcesPercent: d.Get("ha_admission_control_memory_failover_percent").(int),
+		}
+	case haAdmissionControlPolicySlotPolicy:
+		das.AdmissionControlPolicy = &types.ClusterFailoverLevelAdmissionControlPolicy{
+			FailoverLevel: d.Get("ha_admission_control_host_failure_tolerance").(int),
+		}
+	case haAdmissionControlPolicyFailoverHosts:
+		ids := d.Get("ha_admission_control_failover_host_ids").([]interface{})
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("ha_admission_control_failover_host_ids is required when ha_admission_control_policy is %q.", haAdmissionControlPolicyFailoverHosts)
+		}
+		policy := &types.ClusterFailoverHostAdmissionControlPolicy{}
+		for _, id := range ids {
+			policy.FailoverHosts = append(policy.FailoverHosts, hostSystemReference(id.(string)))
+		}
+		das.AdmissionControlPolicy = policy
+	case haAdmissionControlPolicyDisabled:
+		das.AdmissionControlEnabled = types.NewBool(false)
+	}
+
+	dpm := &types.ClusterDpmConfigInfo{
+		Enabled:             types.NewBool(d.Get("dpm_enabled").(bool)),
+		DefaultDpmBehavior:  types.DpmBehavior(d.Get("dpm_automation_level").(string)),
+		HostPowerActionRate: 6 - d.Get("dpm_threshold").(int),
+	}
+
+	return &types.ClusterConfigSpecEx{
+		DrsConfig: drs,
+		DasConfig: das,
+		DpmConfig: dpm,
+	}, nil
+}
+
+// hostSystemReference returns the reference of a host by its managed object ID.
+func hostSystemReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "HostSystem",
+		Value: id,
+	}
+}
+
+// configureEvcMode enables EVC of a cluster with the mode, or disables it if
+// the mode is empty.
+func configureEvcMode(c *govmomi.Client, ref types.ManagedObjectReference, evcMode string) error {
+	res, err := methods.EvcManager(context.TODO(), c.Client, &types.EvcManager{This: ref})
+	if err != nil {
+		return err
+	}
+	if res.Returnval == nil {
+		return fmt.Errorf("EVC is not supported by cluster %s.", ref.Value)
+	}
+
+	var task types.ManagedObjectReference
+	if evcMode == "" {
+		log.Printf("[INFO] Disabling EVC of cluster %s", ref.Value)
+		res, err := methods.DisableEvcMode_Task(context.TODO(), c.Client, &types.DisableEvcMode_Task{This: *res.Returnval})
+		if err != nil {
+			return err
+		}
+		task = res.Returnval
+	} else {
+		log.Printf("[INFO] Configuring EVC mode of cluster %s: %s", ref.Value, evcMode)
+		res, err := methods.ConfigureEvcMode_Task(context.TODO(), c.Client, &types.ConfigureEvcMode_Task{
+			This:       *res.Returnval,
+			EvcModeKey: evcMode,
+		})
+		if err != nil {
+			return err
+		}
+		task = res.Returnval
+	}
+	return object.NewTask(c.Client, task).Wait(context.TODO())
+}
+
+// validateDrsBehavior validates the drs_automation_level argument of vsphere_compute_cluster.
+func validateDrsBehavior(v interface{}, k string) (ws []string, errors []error) {
+	switch types.DrsBehavior(v.(string)) {
+	case types.DrsBehaviorManual, types.DrsBehaviorPartiallyAutomated, types.DrsBehaviorFullyAutomated:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q or %q", k, types.DrsBehaviorManual, types.DrsBehaviorPartiallyAutomated, types.DrsBehaviorFullyAutomated))
+	}
+	return
+}
+
+// validateDpmBehavior validates the dpm_automation_level argument of vsphere_compute_cluster.
+func validateDpmBehavior(v interface{}, k string) (ws []string, errors []error) {
+	switch types.DpmBehavior(v.(string)) {
+	case types.DpmBehaviorManual, types.DpmBehaviorAutomated:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q or %q", k, types.DpmBehaviorManual, types.DpmBehaviorAutomated))
+	}
+	return
+}
+
+// validateClusterThreshold validates the DRS migration threshold and the DPM threshold.
+func validateClusterThreshold(v interface{}, k string) (ws []string, errors []error) {
+	if t := v.(int); t < 1 || t > 5 {
+		errors = append(errors, fmt.Errorf("%q must be between 1 and 5", k))
+	}
+	return
+}
+
+// validateHAAdmissionControlPolicy validates the ha_admission_control_policy argument of vsphere_compute_cluster.
+func validateHAAdmissionControlPolicy(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case haAdmissionControlPolicyResourcePercentage, haAdmissionControlPolicySlotPolicy, haAdmissionControlPolicyFailoverHosts, haAdmissionControlPolicyDisabled:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q, %q or %q", k, haAdmissionControlPolicyResourcePercentage, haAdmissionControlPolicySlotPolicy, haAdmissionControlPolicyFailoverHosts, haAdmissionControlPolicyDisabled))
+	}
+	return
+}
+
+// validateHAVMRestartPriority validates the ha_vm_restart_priority argument of vsphere_compute_cluster.
+func validateHAVMRestartPriority(v interface{}, k string) (ws []string, errors []error) {
+	switch types.ClusterDasVmSettingsRestartPriority(v.(string)) {
+	case types.ClusterDasVmSettingsRestartPriorityDisabled, types.ClusterDasVmSettingsRestartPriorityLow, types.ClusterDasVmSettingsRestartPriorityMedium, types.ClusterDasVmSettingsRestartPriorityHigh:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q, %q or %q", k, types.ClusterDasVmSettingsRestartPriorityDisabled, types.ClusterDasVmSettingsRestartPriorityLow, types.ClusterDasVmSettingsRestartPriorityMedium, types.ClusterDasVmSettingsRestartPriorityHigh))
+	}
+	return
+}
+
+// validateHAIsolationResponse validates the ha_host_isolation_response argument of vsphere_compute_cluster.
+func validateHAIsolationResponse(v interface{}, k string) (ws []string, errors []error) {
+	switch types.ClusterDasVmSettingsIsolationResponse(v.(string)) {
+	case types.ClusterDasVmSettingsIsolationResponseNone, types.ClusterDasVmSettingsIsolationResponsePowerOff, types.ClusterDasVmSettingsIsolationResponseShutdown:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q or %q", k, types.ClusterDasVmSettingsIsolationResponseNone, types.ClusterDasVmSettingsIsolationResponsePowerOff, types.ClusterDasVmSettingsIsolationResponseShutdown))
+	}
+	return
+}
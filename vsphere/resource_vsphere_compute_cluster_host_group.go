@@ -0,0 +1,130 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func resourceVSphereComputeClusterHostGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereComputeClusterHostGroupCreate,
+		Read:   resourceVSphereComputeClusterHostGroupRead,
+		Update: resourceVSphereComputeClusterHostGroupUpdate,
+		Delete: resourceVSphereComputeClusterHostGroupDelete,
+
+		Schema: map[string]*schema.Schema{
+			"compute_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"host_system_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+func resourceVSphereComputeClusterHostGroupCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+	name := d.Get("name").(string)
+
+	config, err := getClusterConfig(client, cluster)
+	if err != nil {
+		return err
+	}
+	if findClusterGroup(config, name) != nil {
+		return fmt.Errorf("Group %s already exists in cluster %s.", name, cluster.Value)
+	}
+
+	group := createClusterHostGroup(d)
+	if err := setClusterGroup(client, cluster, group, types.ArrayUpdateOperationAdd); err != nil {
+		return err
+	}
+
+	d.SetId(clusterObjectID(cluster.Value, name))
+	log.Printf("[INFO] Created host group: %s", d.Id())
+
+	return resourceVSphereComputeClusterHostGroupRead(d, meta)
+}
+
+func resourceVSphereComputeClusterHostGroupRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, name, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	config, err := getClusterConfig(client, clusterReference(clusterID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Cluster not found: %s", clusterID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	group, ok := findClusterGroup(config, name).(*types.ClusterHostGroup)
+	if !ok {
+		log.Printf("[ERROR] host group not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	var ids []string
+	for _, ref := range group.Host {
+		ids = append(ids, ref.Value)
+	}
+	d.Set("compute_cluster_id", clusterID)
+	d.Set("name", name)
+	d.Set("host_system_ids", ids)
+	return nil
+}
+
+func resourceVSphereComputeClusterHostGroupUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+	group := createClusterHostGroup(d)
+	if err := setClusterGroup(client, cluster, group, types.ArrayUpdateOperationEdit); err != nil {
+		return err
+	}
+
+	return resourceVSphereComputeClusterHostGroupRead(d, meta)
+}
+
+func resourceVSphereComputeClusterHostGroupDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+	log.Printf("[INFO] Deleting host group: %s", d.Id())
+	if err := removeClusterGroup(client, cluster, d.Get("name").(string)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// createClusterHostGroup creates ClusterHostGroup from the name and host_system_ids arguments.
+func createClusterHostGroup(d *schema.ResourceData) *types.ClusterHostGroup {
+	return &types.ClusterHostGroup{
+		ClusterGroupInfo: types.ClusterGroupInfo{Name: d.Get("name").(string)},
+		Host:             hostSystemReferences(d.Get("host_system_ids").(*schema.Set)),
+	}
+}
@@ -0,0 +1,140 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccVSphereComputeCluster_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereComputeClusterDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterConfig_basic,
+					datacenter,
+					"manual",
+					3,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterExists("vsphere_compute_cluster.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster.foo", "drs_automation_level", "manual"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster.foo", "drs_migration_threshold", "3"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterConfig_basic,
+					datacenter,
+					"fullyAutomated",
+					5,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterExists("vsphere_compute_cluster.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster.foo", "drs_automation_level", "fullyAutomated"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster.foo", "drs_migration_threshold", "5"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_compute_cluster.foo",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("/%s/host/terraform-test", datacenter),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCreateClusterConfigSpec(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereComputeCluster().Schema, map[string]interface{}{
+		"drs_enabled":                 true,
+		"drs_migration_threshold":     4,
+		"ha_enabled":                  true,
+		"ha_admission_control_policy": "slotPolicy",
+		"ha_admission_control_host_failure_tolerance": 2,
+	})
+
+	spec, err := createClusterConfigSpec(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.DrsConfig.VmotionRate != 2 {
+		t.Fatalf("vmotionRate should be 2: %d", spec.DrsConfig.VmotionRate)
+	}
+	policy, ok := spec.DasConfig.AdmissionControlPolicy.(*types.ClusterFailoverLevelAdmissionControlPolicy)
+	if !ok || policy.FailoverLevel != 2 {
+		t.Fatalf("admission control policy should be the failover level 2: %#v", spec.DasConfig.AdmissionControlPolicy)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceVSphereComputeCluster().Schema, map[string]interface{}{
+		"ha_admission_control_policy": "failoverHosts",
+	})
+	if _, err := createClusterConfigSpec(d); err == nil {
+		t.Fatal("failoverHosts without host IDs should be an error")
+	}
+}
+
+func testAccCheckVSphereComputeClusterDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_compute_cluster" {
+			continue
+		}
+
+		_, err := inventoryPath(client, clusterReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereComputeClusterExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := inventoryPath(client, clusterReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereComputeClusterConfig_basic = `
+resource "vsphere_compute_cluster" "foo" {
+    name = "terraform-test"
+    datacenter = "%s"
+    drs_enabled = true
+    drs_automation_level = "%s"
+    drs_migration_threshold = %d
+    ha_enabled = true
+}
+`
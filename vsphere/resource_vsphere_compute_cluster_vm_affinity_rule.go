@@ -0,0 +1,164 @@
+package vsphere
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// vsphere_compute_cluster_vm_affinity_rule and vsphere_compute_cluster_vm_anti_affinity_rule
+// share the schema and the implementation except the type of the rule.
+
+func resourceVSphereComputeClusterVMAffinityRule() *schema.Resource {
+	return resourceVSphereComputeClusterVMAffinityRuleWithType(false)
+}
+
+func resourceVSphereComputeClusterVMAntiAffinityRule() *schema.Resource {
+	return resourceVSphereComputeClusterVMAffinityRuleWithType(true)
+}
+
+func resourceVSphereComputeClusterVMAffinityRuleWithType(anti bool) *schema.Resource {
+	read := func(d *schema.ResourceData, meta interface{}) error {
+		return resourceVSphereComputeClusterVMAffinityRuleRead(d, meta, anti)
+	}
+	return &schema.Resource{
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			client := meta.(*VSphereClient).vimClient
+			cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+			rule, err := createClusterVMAffinityRule(client, d, cluster, anti)
+			if err != nil {
+				return err
+			}
+			key, err := addClusterRule(client, cluster, rule)
+			if err != nil {
+				return err
+			}
+
+			d.SetId(clusterObjectID(cluster.Value, strconv.Itoa(key)))
+			log.Printf("[INFO] Created VM affinity rule: %s", d.Id())
+
+			return read(d, meta)
+		},
+		Read: read,
+		Update: func(d *schema.ResourceData, meta interface{}) error {
+			client := meta.(*VSphereClient).vimClient
+			clusterID, key, err := parseClusterRuleID(d.Id())
+			if err != nil {
+				return err
+			}
+			cluster := clusterReference(clusterID)
+
+			rule, err := createClusterVMAffinityRule(client, d, cluster, anti)
+			if err != nil {
+				return err
+			}
+			rule.GetClusterRuleInfo().Key = key
+			if err := editClusterRule(client, cluster, rule); err != nil {
+				return err
+			}
+
+			return read(d, meta)
+		},
+		Delete: deleteClusterRule,
+
+		Schema: map[string]*schema.Schema{
+			"compute_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"mandatory": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"virtual_machines": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+func resourceVSphereComputeClusterVMAffinityRuleRead(d *schema.ResourceData, meta interface{}, anti bool) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, key, err := parseClusterRuleID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	config, err := getClusterConfig(client, clusterReference(clusterID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Cluster not found: %s", clusterID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	var vms []types.ManagedObjectReference
+	var info *types.ClusterRuleInfo
+	switch rule := findClusterRule(config, key).(type) {
+	case *types.ClusterAffinityRuleSpec:
+		if !anti {
+			vms, info = rule.Vm, &rule.ClusterRuleInfo
+		}
+	case *types.ClusterAntiAffinityRuleSpec:
+		if anti {
+			vms, info = rule.Vm, &rule.ClusterRuleInfo
+		}
+	}
+	if info == nil {
+		log.Printf("[ERROR] VM affinity rule not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	paths, err := virtualMachinePaths(client, vms)
+	if err != nil {
+		return err
+	}
+	d.Set("compute_cluster_id", clusterID)
+	setClusterRuleInfo(d, info)
+	d.Set("virtual_machines", paths)
+	return nil
+}
+
+// createClusterVMAffinityRule creates ClusterAffinityRuleSpec or
+// ClusterAntiAffinityRuleSpec from the arguments of the rule resources.
+func createClusterVMAffinityRule(c *govmomi.Client, d *schema.ResourceData, cluster types.ManagedObjectReference, anti bool) (types.BaseClusterRuleInfo, error) {
+	vms, err := findVirtualMachineReferences(c, cluster, d.Get("virtual_machines").(*schema.Set))
+	if err != nil {
+		return nil, err
+	}
+	if anti {
+		return &types.ClusterAntiAffinityRuleSpec{
+			ClusterRuleInfo: createClusterRuleInfo(d),
+			Vm:              vms,
+		}, nil
+	}
+	return &types.ClusterAffinityRuleSpec{
+		ClusterRuleInfo: createClusterRuleInfo(d),
+		Vm:              vms,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVSphereComputeClusterVMAntiAffinityRule_basic(t *testing.T) {
+	clusterID := os.Getenv("VSPHERE_CLUSTER_ID")
+	vm := os.Getenv("VSPHERE_VM")
+	vm2 := os.Getenv("VSPHERE_VM2")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterVMAntiAffinityRuleConfig_basic,
+					clusterID,
+					vm,
+					vm2,
+					"true",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterVMHostRuleExists("vsphere_compute_cluster_vm_anti_affinity_rule.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_anti_affinity_rule.foo", "virtual_machines.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_anti_affinity_rule.foo", "enabled", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterVMAntiAffinityRuleConfig_basic,
+					clusterID,
+					vm,
+					vm2,
+					"false",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterVMHostRuleExists("vsphere_compute_cluster_vm_anti_affinity_rule.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_anti_affinity_rule.foo", "enabled", "false"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVSphereComputeClusterVMAntiAffinityRuleConfig_basic = `
+resource "vsphere_compute_cluster_vm_anti_affinity_rule" "foo" {
+    compute_cluster_id = "%s"
+    name = "terraform-test"
+    virtual_machines = ["%s", "%s"]
+    enabled = %s
+}
+`
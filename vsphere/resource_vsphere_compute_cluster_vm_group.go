@@ -0,0 +1,141 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func resourceVSphereComputeClusterVMGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereComputeClusterVMGroupCreate,
+		Read:   resourceVSphereComputeClusterVMGroupRead,
+		Update: resourceVSphereComputeClusterVMGroupUpdate,
+		Delete: resourceVSphereComputeClusterVMGroupDelete,
+
+		Schema: map[string]*schema.Schema{
+			"compute_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"virtual_machines": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+func resourceVSphereComputeClusterVMGroupCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+	name := d.Get("name").(string)
+
+	config, err := getClusterConfig(client, cluster)
+	if err != nil {
+		return err
+	}
+	if findClusterGroup(config, name) != nil {
+		return fmt.Errorf("Group %s already exists in cluster %s.", name, cluster.Value)
+	}
+
+	group, err := createClusterVMGroup(client, d, cluster)
+	if err != nil {
+		return err
+	}
+	if err := setClusterGroup(client, cluster, group, types.ArrayUpdateOperationAdd); err != nil {
+		return err
+	}
+
+	d.SetId(clusterObjectID(cluster.Value, name))
+	log.Printf("[INFO] Created VM group: %s", d.Id())
+
+	return resourceVSphereComputeClusterVMGroupRead(d, meta)
+}
+
+func resourceVSphereComputeClusterVMGroupRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, name, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	config, err := getClusterConfig(client, clusterReference(clusterID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Cluster not found: %s", clusterID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	group, ok := findClusterGroup(config, name).(*types.ClusterVmGroup)
+	if !ok {
+		log.Printf("[ERROR] VM group not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	paths, err := virtualMachinePaths(client, group.Vm)
+	if err != nil {
+		return err
+	}
+	d.Set("compute_cluster_id", clusterID)
+	d.Set("name", name)
+	d.Set("virtual_machines", paths)
+	return nil
+}
+
+func resourceVSphereComputeClusterVMGroupUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+	group, err := createClusterVMGroup(client, d, cluster)
+	if err != nil {
+		return err
+	}
+	if err := setClusterGroup(client, cluster, group, types.ArrayUpdateOperationEdit); err != nil {
+		return err
+	}
+
+	return resourceVSphereComputeClusterVMGroupRead(d, meta)
+}
+
+func resourceVSphereComputeClusterVMGroupDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+	log.Printf("[INFO] Deleting VM group: %s", d.Id())
+	if err := removeClusterGroup(client, cluster, d.Get("name").(string)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// createClusterVMGroup creates ClusterVmGroup from the name and virtual_machines arguments.
+func createClusterVMGroup(c *govmomi.Client, d *schema.ResourceData, cluster types.ManagedObjectReference) (*types.ClusterVmGroup, error) {
+	vms, err := findVirtualMachineReferences(c, cluster, d.Get("virtual_machines").(*schema.Set))
+	if err != nil {
+		return nil, err
+	}
+	return &types.ClusterVmGroup{
+		ClusterGroupInfo: types.ClusterGroupInfo{Name: d.Get("name").(string)},
+		Vm:               vms,
+	}, nil
+}
@@ -0,0 +1,196 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func resourceVSphereComputeClusterVMHostRule() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereComputeClusterVMHostRuleCreate,
+		Read:   resourceVSphereComputeClusterVMHostRuleRead,
+		Update: resourceVSphereComputeClusterVMHostRuleUpdate,
+		Delete: resourceVSphereComputeClusterVMHostRuleDelete,
+
+		Schema: map[string]*schema.Schema{
+			"compute_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"mandatory": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"vm_group_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"affinity_host_group_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"anti_affinity_host_group_name"},
+			},
+
+			"anti_affinity_host_group_name": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"affinity_host_group_name"},
+			},
+		},
+	}
+}
+
+func resourceVSphereComputeClusterVMHostRuleCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	cluster := clusterReference(d.Get("compute_cluster_id").(string))
+
+	rule, err := createClusterVMHostRule(d)
+	if err != nil {
+		return err
+	}
+	key, err := addClusterRule(client, cluster, rule)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(clusterObjectID(cluster.Value, strconv.Itoa(key)))
+	log.Printf("[INFO] Created VM/host rule: %s", d.Id())
+
+	return resourceVSphereComputeClusterVMHostRuleRead(d, meta)
+}
+
+func resourceVSphereComputeClusterVMHostRuleRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, key, err := parseClusterRuleID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	config, err := getClusterConfig(client, clusterReference(clusterID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Cluster not found: %s", clusterID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	rule, ok := findClusterRule(config, key).(*types.ClusterVmHostRuleInfo)
+	if !ok {
+		log.Printf("[ERROR] VM/host rule not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("compute_cluster_id", clusterID)
+	setClusterRuleInfo(d, &rule.ClusterRuleInfo)
+	d.Set("vm_group_name", rule.VmGroupName)
+	d.Set("affinity_host_group_name", rule.AffineHostGroupName)
+	d.Set("anti_affinity_host_group_name", rule.AntiAffineHostGroupName)
+	return nil
+}
+
+func resourceVSphereComputeClusterVMHostRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, key, err := parseClusterRuleID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	rule, err := createClusterVMHostRule(d)
+	if err != nil {
+		return err
+	}
+	rule.Key = key
+	if err := editClusterRule(client, clusterReference(clusterID), rule); err != nil {
+		return err
+	}
+
+	return resourceVSphereComputeClusterVMHostRuleRead(d, meta)
+}
+
+func resourceVSphereComputeClusterVMHostRuleDelete(d *schema.ResourceData, meta interface{}) error {
+	return deleteClusterRule(d, meta)
+}
+
+// createClusterVMHostRule creates ClusterVmHostRuleInfo from the arguments of vsphere_compute_cluster_vm_host_rule.
+func createClusterVMHostRule(d *schema.ResourceData) (*types.ClusterVmHostRuleInfo, error) {
+	rule := &types.ClusterVmHostRuleInfo{
+		ClusterRuleInfo:         createClusterRuleInfo(d),
+		VmGroupName:             d.Get("vm_group_name").(string),
+		AffineHostGroupName:     d.Get("affinity_host_group_name").(string),
+		AntiAffineHostGroupName: d.Get("anti_affinity_host_group_name").(string),
+	}
+	if rule.AffineHostGroupName == "" && rule.AntiAffineHostGroupName == "" {
+		return nil, fmt.Errorf("Either affinity_host_group_name or anti_affinity_host_group_name must be specified.")
+	}
+	return rule, nil
+}
+
+// createClusterRuleInfo creates ClusterRuleInfo from the name, enabled and mandatory arguments.
+func createClusterRuleInfo(d *schema.ResourceData) types.ClusterRuleInfo {
+	return types.ClusterRuleInfo{
+		Name:        d.Get("name").(string),
+		Enabled:     types.NewBool(d.Get("enabled").(bool)),
+		Mandatory:   types.NewBool(d.Get("mandatory").(bool)),
+		UserCreated: types.NewBool(true),
+	}
+}
+
+// setClusterRuleInfo sets the name, enabled and mandatory arguments from ClusterRuleInfo.
+func setClusterRuleInfo(d *schema.ResourceData, rule *types.ClusterRuleInfo) {
+	d.Set("name", rule.Name)
+	d.Set("enabled", rule.Enabled != nil && *rule.Enabled)
+	d.Set("mandatory", rule.Mandatory != nil && *rule.Mandatory)
+}
+
+// parseClusterRuleID parses the ID of a rule of a cluster into the cluster ID and the rule key.
+func parseClusterRuleID(id string) (string, int, error) {
+	clusterID, k, err := parseClusterObjectID(id)
+	if err != nil {
+		return "", 0, err
+	}
+	key, err := strconv.Atoi(k)
+	if err != nil {
+		return "", 0, fmt.Errorf("Invalid rule key in ID %q: %s", id, err)
+	}
+	return clusterID, key, nil
+}
+
+// deleteClusterRule deletes the rule of a rule resource by its key.
+func deleteClusterRule(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	clusterID, key, err := parseClusterRuleID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting rule: %s", d.Id())
+	if err := removeClusterRule(client, clusterReference(clusterID), key); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
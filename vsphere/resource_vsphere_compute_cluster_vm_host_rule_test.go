@@ -0,0 +1,150 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereComputeClusterVMHostRule_basic(t *testing.T) {
+	clusterID := os.Getenv("VSPHERE_CLUSTER_ID")
+	hostID := os.Getenv("VSPHERE_HOST_ID")
+	vm := os.Getenv("VSPHERE_VM")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereComputeClusterVMHostRuleDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterVMHostRuleConfig_basic,
+					clusterID,
+					vm,
+					clusterID,
+					hostID,
+					clusterID,
+					"false",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterVMHostRuleExists("vsphere_compute_cluster_vm_host_rule.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_group.foo", "virtual_machines.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_host_group.foo", "host_system_ids.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_host_rule.foo", "mandatory", "false"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereComputeClusterVMHostRuleConfig_basic,
+					clusterID,
+					vm,
+					clusterID,
+					hostID,
+					clusterID,
+					"true",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereComputeClusterVMHostRuleExists("vsphere_compute_cluster_vm_host_rule.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_compute_cluster_vm_host_rule.foo", "mandatory", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestParseClusterRuleID(t *testing.T) {
+	clusterID, key, err := parseClusterRuleID("domain-c7:42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clusterID != "domain-c7" || key != 42 {
+		t.Fatalf("ID should be parsed into domain-c7 and 42: %s, %d", clusterID, key)
+	}
+
+	for _, id := range []string{"", "domain-c7", "domain-c7:", ":42", "domain-c7:db"} {
+		if _, _, err := parseClusterRuleID(id); err == nil {
+			t.Fatalf("%q should be invalid", id)
+		}
+	}
+}
+
+func testAccCheckVSphereComputeClusterVMHostRuleDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_compute_cluster_vm_host_rule" {
+			continue
+		}
+
+		clusterID, key, err := parseClusterRuleID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		config, err := getClusterConfig(client, clusterReference(clusterID))
+		if err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+		if findClusterRule(config, key) != nil {
+			return fmt.Errorf("Record still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereComputeClusterVMHostRuleExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		clusterID, key, err := parseClusterRuleID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		config, err := getClusterConfig(client, clusterReference(clusterID))
+		if err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+		if findClusterRule(config, key) == nil {
+			return fmt.Errorf("Rule not found: %s", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereComputeClusterVMHostRuleConfig_basic = `
+resource "vsphere_compute_cluster_vm_group" "foo" {
+    compute_cluster_id = "%s"
+    name = "terraform-test-vms"
+    virtual_machines = ["%s"]
+}
+
+resource "vsphere_compute_cluster_host_group" "foo" {
+    compute_cluster_id = "%s"
+    name = "terraform-test-hosts"
+    host_system_ids = ["%s"]
+}
+
+resource "vsphere_compute_cluster_vm_host_rule" "foo" {
+    compute_cluster_id = "%s"
+    name = "terraform-test"
+    vm_group_name = "${vsphere_compute_cluster_vm_group.foo.name}"
+    affinity_host_group_name = "${vsphere_compute_cluster_host_group.foo.name}"
+    mandatory = %s
+}
+`
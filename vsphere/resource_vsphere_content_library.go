@@ -0,0 +1,216 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/find"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereContentLibrary() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereContentLibraryCreate,
+		Read:   resourceVSphereContentLibraryRead,
+		Update: resourceVSphereContentLibraryUpdate,
+		Delete: resourceVSphereContentLibraryDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: false,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"datastore": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"published": &schema.Schema{
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"subscription"},
+			},
+
+			"publish_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"subscription": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"subscription_url": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"user_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+
+						"password": &schema.Schema{
+							Type:      schema.TypeString,
+							Optional:  true,
+							ForceNew:  true,
+							Sensitive: true,
+						},
+
+						"automatic_sync": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+							ForceNew: true,
+						},
+
+						"on_demand": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceVSphereContentLibraryCreate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.vimClient
+
+	dc, err := getDatacenter(client, d.Get("datacenter").(string))
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(client.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	datastore, err := finder.Datastore(context.TODO(), d.Get("datastore").(string))
+	if err != nil {
+		return err
+	}
+
+	library := contentLibrary{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        "LOCAL",
+		StorageBackings: []contentLibraryStorageBacking{
+			{
+				Type:        "DATASTORE",
+				DatastoreID: datastore.Reference().Value,
+			},
+		},
+		PublishInfo: &contentLibraryPublishInfo{
+			Published:            d.Get("published").(bool),
+			AuthenticationMethod: "NONE",
+		},
+	}
+
+	if v, ok := d.GetOk("subscription"); ok {
+		s := v.([]interface{})[0].(map[string]interface{})
+		library.Type = "SUBSCRIBED"
+		library.PublishInfo = nil
+		library.SubscriptionInfo = &contentLibrarySubscriptionInfo{
+			SubscriptionURL:      s["subscription_url"].(string),
+			AuthenticationMethod: "NONE",
+			AutomaticSyncEnabled: s["automatic_sync"].(bool),
+			OnDemand:             s["on_demand"].(bool),
+		}
+		if user := s["user_name"].(string); user != "" {
+			library.SubscriptionInfo.AuthenticationMethod = "BASIC"
+			library.SubscriptionInfo.UserName = user
+			library.SubscriptionInfo.Password = s["password"].(string)
+		}
+	}
+	log.Printf("[DEBUG] content library: %#v", library)
+
+	id, err := createContentLibrary(providerClient.restClient, library)
+	if err != nil {
+		return fmt.Errorf("Error creating content library: %s", err)
+	}
+	d.SetId(id)
+	log.Printf("[INFO] Created content library: %s", d.Id())
+
+	return resourceVSphereContentLibraryRead(d, meta)
+}
+
+func resourceVSphereContentLibraryRead(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	library, err := getContentLibrary(providerClient.restClient, d.Id())
+	if err != nil {
+		if isRestNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	log.Printf("[DEBUG] content library: %#v", library)
+
+	d.Set("name", library.Name)
+	d.Set("description", library.Description)
+	if library.PublishInfo != nil {
+		d.Set("published", library.PublishInfo.Published)
+		d.Set("publish_url", library.PublishInfo.PublishURL)
+	}
+	return nil
+}
+
+func resourceVSphereContentLibraryUpdate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	_, subscribed := d.GetOk("subscription")
+	library := contentLibrary{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+	}
+	if !subscribed && d.HasChange("published") {
+		library.PublishInfo = &contentLibraryPublishInfo{
+			Published:            d.Get("published").(bool),
+			AuthenticationMethod: "NONE",
+		}
+	}
+
+	if err := updateContentLibrary(providerClient.restClient, d.Id(), subscribed, library); err != nil {
+		return fmt.Errorf("Error updating content library: %s", err)
+	}
+
+	return resourceVSphereContentLibraryRead(d, meta)
+}
+
+func resourceVSphereContentLibraryDelete(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	_, subscribed := d.GetOk("subscription")
+	if err := deleteContentLibrary(providerClient.restClient, d.Id(), subscribed); err != nil {
+		return fmt.Errorf("Error deleting content library: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,140 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVSphereContentLibraryItem() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereContentLibraryItemCreate,
+		Read:   resourceVSphereContentLibraryItemRead,
+		Update: resourceVSphereContentLibraryItemUpdate,
+		Delete: resourceVSphereContentLibraryItemDelete,
+
+		Schema: map[string]*schema.Schema{
+			"library_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: false,
+			},
+
+			"file_url": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"allow_unverified_ssl": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceVSphereContentLibraryItemCreate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.restClient
+
+	fileURL := d.Get("file_url").(string)
+	item := contentLibraryItem{
+		LibraryID:   d.Get("library_id").(string),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        contentLibraryItemType(fileURL),
+	}
+	if v, ok := d.GetOk("type"); ok {
+		item.Type = v.(string)
+	}
+
+	files, err := contentLibraryItemFiles(fileURL, item.Type, item.Name, d.Get("allow_unverified_ssl").(bool))
+	if err != nil {
+		return err
+	}
+
+	id, err := createContentLibraryItem(client, item)
+	if err != nil {
+		return fmt.Errorf("Error creating content library item: %s", err)
+	}
+	d.SetId(id)
+	log.Printf("[INFO] Created content library item: %s", d.Id())
+
+	if err := uploadContentLibraryItem(client, id, files); err != nil {
+		if e := deleteContentLibraryItem(client, id); e != nil {
+			log.Printf("[ERROR] Failed to delete content library item: %s", e)
+		}
+		d.SetId("")
+		return fmt.Errorf("Error uploading content library item: %s", err)
+	}
+
+	return resourceVSphereContentLibraryItemRead(d, meta)
+}
+
+func resourceVSphereContentLibraryItemRead(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	item, err := getContentLibraryItem(providerClient.restClient, d.Id())
+	if err != nil {
+		if isRestNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	log.Printf("[DEBUG] content library item: %#v", item)
+
+	d.Set("library_id", item.LibraryID)
+	d.Set("name", item.Name)
+	d.Set("description", item.Description)
+	d.Set("type", item.Type)
+	return nil
+}
+
+func resourceVSphereContentLibraryItemUpdate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	item := contentLibraryItem{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+	}
+	if err := updateContentLibraryItem(providerClient.restClient, d.Id(), item); err != nil {
+		return fmt.Errorf("Error updating content library item: %s", err)
+	}
+
+	return resourceVSphereContentLibraryItemRead(d, meta)
+}
+
+func resourceVSphereContentLibraryItemDelete(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+
+	if err := deleteContentLibraryItem(providerClient.restClient, d.Id()); err != nil {
+		return fmt.Errorf("Error deleting content library item: %s", err)
+	}
+
+	d.SetId("")
+	return nil
+}
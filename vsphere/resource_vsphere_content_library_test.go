@@ -0,0 +1,100 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereContentLibrary_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereContentLibraryDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereContentLibraryConfig_basic,
+					datacenter,
+					datastore,
+					"terraform test",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereContentLibraryExists("vsphere_content_library.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_content_library.foo", "name", "terraform-test"),
+					resource.TestCheckResourceAttr(
+						"vsphere_content_library.foo", "description", "terraform test"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereContentLibraryConfig_basic,
+					datacenter,
+					datastore,
+					"terraform test updated",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereContentLibraryExists("vsphere_content_library.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_content_library.foo", "description", "terraform test updated"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVSphereContentLibraryDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).restClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_content_library" {
+			continue
+		}
+
+		_, err := getContentLibrary(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isRestNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereContentLibraryExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).restClient
+		if _, err := getContentLibrary(client, rs.Primary.ID); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereContentLibraryConfig_basic = `
+resource "vsphere_content_library" "foo" {
+    name = "terraform-test"
+    datacenter = "%s"
+    datastore = "%s"
+    description = "%s"
+}
+`
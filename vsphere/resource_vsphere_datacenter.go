@@ -0,0 +1,212 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereDatacenter() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereDatacenterCreate,
+		Read:   resourceVSphereDatacenterRead,
+		Update: resourceVSphereDatacenterUpdate,
+		Delete: resourceVSphereDatacenterDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereDatacenterImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"folder": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validateFolderPath,
+			},
+
+			"custom_attributes": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+			},
+
+			"tags": tagsSchema(),
+
+			"force_destroy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceVSphereDatacenterCreate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.vimClient
+
+	folder, err := getDatacenterFolder(client, d.Get("folder").(string))
+	if err != nil {
+		return err
+	}
+
+	dc, err := folder.CreateDatacenter(context.TODO(), d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(dc.Reference().Value)
+	log.Printf("[INFO] Created datacenter: %s", d.Id())
+
+	if err := applyCustomAttributes(client, dc.Reference(), d); err != nil {
+		return err
+	}
+	if err := applyTags(providerClient.restClient, dc.Reference(), d); err != nil {
+		return err
+	}
+
+	return resourceVSphereDatacenterRead(d, meta)
+}
+
+func resourceVSphereDatacenterRead(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.vimClient
+	ref := datacenterReference(d.Id())
+
+	p, err := inventoryPath(client, ref)
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datacenter not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	d.Set("name", path.Base(p))
+	d.Set("folder", strings.TrimPrefix(path.Dir(p), "/"))
+
+	attributes, err := getCustomAttributes(client, ref, d.Get("custom_attributes").(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+	d.Set("custom_attributes", attributes)
+
+	// Tags are read only when they're managed, since reading them needs
+	// the tagging API of vCenter. Tags attached by others are left out.
+	if managed := d.Get("tags").(*schema.Set); managed.Len() > 0 {
+		tags, err := getTags(providerClient.restClient, ref)
+		if err != nil {
+			return err
+		}
+		d.Set("tags", filterTags(tags, managed))
+	}
+	return nil
+}
+
+func resourceVSphereDatacenterUpdate(d *schema.ResourceData, meta interface{}) error {
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.vimClient
+	ref := datacenterReference(d.Id())
+
+	if d.HasChange("name") {
+		log.Printf("[INFO] Renaming datacenter %s to %s", d.Id(), d.Get("name").(string))
+		if err := renameEntity(client, ref, d.Get("name").(string)); err != nil {
+			return err
+		}
+	}
+
+	if err := applyCustomAttributes(client, ref, d); err != nil {
+		return err
+	}
+	if err := applyTags(providerClient.restClient, ref, d); err != nil {
+		return err
+	}
+
+	return resourceVSphereDatacenterRead(d, meta)
+}
+
+func resourceVSphereDatacenterDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	dc := object.NewDatacenter(client.Client, datacenterReference(d.Id()))
+
+	var mdc mo.Datacenter
+	if err := dc.Properties(context.TODO(), dc.Reference(), []string{"vmFolder", "hostFolder", "datastoreFolder", "networkFolder"}, &mdc); err != nil {
+		return err
+	}
+	var folders []mo.Folder
+	refs := []types.ManagedObjectReference{mdc.VmFolder, mdc.HostFolder, mdc.DatastoreFolder, mdc.NetworkFolder}
+	if err := property.DefaultCollector(client.Client).Retrieve(context.TODO(), refs, []string{"childEntity"}, &folders); err != nil {
+		return err
+	}
+	children := 0
+	for _, f := range folders {
+		children += len(f.ChildEntity)
+	}
+	if children > 0 && !d.Get("force_destroy").(bool) {
+		return fmt.Errorf("Datacenter %s is not empty: set force_destroy to delete it with its %d children.", d.Get("name").(string), children)
+	}
+
+	log.Printf("[INFO] Deleting datacenter: %s", d.Id())
+	task, err := dc.Destroy(context.TODO())
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereDatacenterImport imports a datacenter by its inventory
+// path, such as "/folder/Datacenter".
+func resourceVSphereDatacenterImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	p := d.Id()
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("Invalid datacenter path %q: it must be like /<folder>/<datacenter>.", p)
+	}
+
+	finder := find.NewFinder(client.Client, true)
+	dc, err := finder.Datacenter(context.TODO(), p)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("force_destroy", false)
+
+	d.SetId(dc.Reference().Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// datacenterReference returns the reference of a datacenter by its managed object ID.
+func datacenterReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "Datacenter",
+		Value: id,
+	}
+}
+
+// getDatacenterFolder gets the folder of datacenters at the path relative to
+// the root folder. An empty path means the root folder.
+func getDatacenterFolder(c *govmomi.Client, p string) (*object.Folder, error) {
+	if p == "" {
+		return object.NewRootFolder(c.Client), nil
+	}
+	finder := find.NewFinder(c.Client, true)
+	return finder.Folder(context.TODO(), path.Join("/", p))
+}
@@ -0,0 +1,121 @@
+package vsphere
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccVSphereDatacenter_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereDatacenterDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVSphereDatacenterConfig_basic, "terraform-test"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereDatacenterExists("vsphere_datacenter.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datacenter.foo", "name", "terraform-test"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datacenter.foo", "folder", ""),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVSphereDatacenterConfig_basic, "terraform-test-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereDatacenterExists("vsphere_datacenter.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datacenter.foo", "name", "terraform-test-renamed"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_datacenter.foo",
+				ImportState:       true,
+				ImportStateId:     "/terraform-test-renamed",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCustomAttributeValues(t *testing.T) {
+	var me mo.ManagedEntity
+	me.AvailableField = []types.CustomFieldDef{
+		{Key: 1, Name: "owner"},
+		{Key: 2, Name: "backup"},
+		{Key: 3, Name: "cost-center"},
+	}
+	me.CustomValue = []types.BaseCustomFieldValue{
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 1}, Value: "team-a"},
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 2}, Value: ""},
+		&types.CustomFieldStringValue{CustomFieldValue: types.CustomFieldValue{Key: 3}, Value: "1234"},
+	}
+
+	// cost-center isn't managed, and backup is cleared.
+	values := customAttributeValues(me, map[string]interface{}{"owner": "", "backup": ""})
+	if !reflect.DeepEqual(values, map[string]string{"owner": "team-a"}) {
+		t.Fatalf("bad: %#v", values)
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	managed := schema.NewSet(schema.HashString, []interface{}{"urn:tag-1", "urn:tag-2"})
+	tags := filterTags([]string{"urn:tag-1", "urn:tag-3"}, managed)
+	if !reflect.DeepEqual(tags, []string{"urn:tag-1"}) {
+		t.Fatalf("bad: %#v", tags)
+	}
+}
+
+func testAccCheckVSphereDatacenterDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_datacenter" {
+			continue
+		}
+
+		_, err := inventoryPath(client, datacenterReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereDatacenterExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := inventoryPath(client, datacenterReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereDatacenterConfig_basic = `
+resource "vsphere_datacenter" "foo" {
+    name = "%s"
+}
+`
@@ -0,0 +1,309 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereDatastoreCluster() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereDatastoreClusterCreate,
+		Read:   resourceVSphereDatastoreClusterRead,
+		Update: resourceVSphereDatastoreClusterUpdate,
+		Delete: resourceVSphereDatastoreClusterDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereDatastoreClusterImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"datastore_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"sdrs_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"sdrs_automation_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.StorageDrsPodConfigInfoBehaviorManual),
+				ValidateFunc: validateStorageDrsBehavior,
+			},
+
+			"sdrs_space_utilization_threshold": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      80,
+				ValidateFunc: validateSpaceUtilizationThreshold,
+			},
+
+			"sdrs_io_load_balance_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"sdrs_io_latency_threshold": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      15,
+				ValidateFunc: validateIOLatencyThreshold,
+			},
+
+			"sdrs_load_balance_interval": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  480,
+			},
+
+			"sdrs_default_intra_vm_affinity": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceVSphereDatastoreClusterCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	folder, err := getRootFolder(client, d.Get("datacenter").(string), folderTypeDatastore)
+	if err != nil {
+		return err
+	}
+
+	res, err := methods.CreateStoragePod(context.TODO(), client.Client, &types.CreateStoragePod{
+		This: folder.Reference(),
+		Name: d.Get("name").(string),
+	})
+	if err != nil {
+		return err
+	}
+	pod := res.Returnval
+
+	d.SetId(pod.Value)
+	log.Printf("[INFO] Created datastore cluster: %s", d.Id())
+
+	if err := configureStorageDrs(client, pod, createStorageDrsConfigSpec(d)); err != nil {
+		return err
+	}
+
+	if ids := stringSet(d.Get("datastore_ids").(*schema.Set)); len(ids) > 0 {
+		log.Printf("[INFO] Moving datastores into datastore cluster %s: %v", d.Id(), ids)
+		if err := moveIntoFolder(client, object.NewFolder(client.Client, pod), datastoreReferences(ids)...); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereDatastoreClusterRead(d, meta)
+}
+
+func resourceVSphereDatastoreClusterRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	msp, err := getStoragePod(client, storagePodReference(d.Id()))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datastore cluster not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	var ids []string
+	for _, ref := range msp.ChildEntity {
+		if ref.Type == "Datastore" {
+			ids = append(ids, ref.Value)
+		}
+	}
+	d.Set("name", msp.Name)
+	d.Set("datastore_ids", ids)
+
+	config := msp.PodStorageDrsEntry.StorageDrsConfig.PodConfig
+	d.Set("sdrs_enabled", config.Enabled)
+	d.Set("sdrs_automation_level", config.DefaultVmBehavior)
+	d.Set("sdrs_io_load_balance_enabled", config.IoLoadBalanceEnabled)
+	if config.LoadBalanceInterval != 0 {
+		d.Set("sdrs_load_balance_interval", config.LoadBalanceInterval)
+	}
+	d.Set("sdrs_default_intra_vm_affinity", config.DefaultIntraVmAffinity == nil || *config.DefaultIntraVmAffinity)
+	if space := config.SpaceLoadBalanceConfig; space != nil && space.SpaceUtilizationThreshold != 0 {
+		d.Set("sdrs_space_utilization_threshold", space.SpaceUtilizationThreshold)
+	}
+	if io := config.IoLoadBalanceConfig; io != nil && io.IoLatencyThreshold != 0 {
+		d.Set("sdrs_io_latency_threshold", io.IoLatencyThreshold)
+	}
+	return nil
+}
+
+func resourceVSphereDatastoreClusterUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pod := storagePodReference(d.Id())
+
+	if d.HasChange("name") {
+		log.Printf("[INFO] Renaming datastore cluster %s to %s", d.Id(), d.Get("name").(string))
+		if err := renameEntity(client, pod, d.Get("name").(string)); err != nil {
+			return err
+		}
+	}
+
+	if err := configureStorageDrs(client, pod, createStorageDrsConfigSpec(d)); err != nil {
+		return err
+	}
+
+	if d.HasChange("datastore_ids") {
+		o, n := d.GetChange("datastore_ids")
+		added := stringSet(n.(*schema.Set).Difference(o.(*schema.Set)))
+		removed := stringSet(o.(*schema.Set).Difference(n.(*schema.Set)))
+		if len(removed) > 0 {
+			if err := moveOutOfStoragePod(client, pod, datastoreReferences(removed)); err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			log.Printf("[INFO] Moving datastores into datastore cluster %s: %v", d.Id(), added)
+			if err := moveIntoFolder(client, object.NewFolder(client.Client, pod), datastoreReferences(added)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceVSphereDatastoreClusterRead(d, meta)
+}
+
+func resourceVSphereDatastoreClusterDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pod := storagePodReference(d.Id())
+
+	// The datastores are kept in the datastore folder.
+	msp, err := getStoragePod(client, pod)
+	if err != nil {
+		return err
+	}
+	if len(msp.ChildEntity) > 0 {
+		if err := moveOutOfStoragePod(client, pod, msp.ChildEntity); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[INFO] Deleting datastore cluster: %s", d.Id())
+	task, err := object.NewFolder(client.Client, pod).Destroy(context.TODO())
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereDatastoreClusterImport imports a datastore cluster by its
+// inventory path, such as "/Datacenter/datastore/DatastoreCluster".
+func resourceVSphereDatastoreClusterImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	p := d.Id()
+	i := strings.Index(p, "/datastore/")
+	if !strings.HasPrefix(p, "/") || i < 1 {
+		return nil, fmt.Errorf("Invalid datastore cluster path %q: it must be like /<datacenter>/datastore/<datastore cluster>.", p)
+	}
+
+	ref, err := object.NewSearchIndex(client.Client).FindByInventoryPath(context.TODO(), p)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil || ref.Reference().Type != "StoragePod" {
+		return nil, fmt.Errorf("Datastore cluster %s not found.", p)
+	}
+
+	d.SetId(ref.Reference().Value)
+	d.Set("datacenter", p[1:i])
+	return []*schema.ResourceData{d}, nil
+}
+
+// createStorageDrsConfigSpec creates StorageDrsConfigSpec from the sdrs arguments.
+func createStorageDrsConfigSpec(d *schema.ResourceData) types.StorageDrsConfigSpec {
+	return types.StorageDrsConfigSpec{
+		PodConfigSpec: &types.StorageDrsPodConfigSpec{
+			Enabled:                types.NewBool(d.Get("sdrs_enabled").(bool)),
+			IoLoadBalanceEnabled:   types.NewBool(d.Get("sdrs_io_load_balance_enabled").(bool)),
+			DefaultVmBehavior:      d.Get("sdrs_automation_level").(string),
+			LoadBalanceInterval:    d.Get("sdrs_load_balance_interval").(int),
+			DefaultIntraVmAffinity: types.NewBool(d.Get("sdrs_default_intra_vm_affinity").(bool)),
+			SpaceLoadBalanceConfig: &types.StorageDrsSpaceLoadBalanceConfig{
+				SpaceThresholdMode:        string(types.StorageDrsSpaceLoadBalanceConfigSpaceThresholdModeUtilization),
+				SpaceUtilizationThreshold: d.Get("sdrs_space_utilization_threshold").(int),
+			},
+			IoLoadBalanceConfig: &types.StorageDrsIoLoadBalanceConfig{
+				IoLatencyThreshold: d.Get("sdrs_io_latency_threshold").(int),
+			},
+		},
+	}
+}
+
+// datastoreReferences returns the references of datastores by their managed object IDs.
+func datastoreReferences(ids []string) []types.ManagedObjectReference {
+	var refs []types.ManagedObjectReference
+	for _, id := range ids {
+		refs = append(refs, datastoreReference(id))
+	}
+	return refs
+}
+
+// moveOutOfStoragePod moves datastores out of a datastore cluster to the
+// datastore folder of its datacenter.
+func moveOutOfStoragePod(c *govmomi.Client, pod types.ManagedObjectReference, refs []types.ManagedObjectReference) error {
+	dc, err := getEntityDatacenter(c, pod)
+	if err != nil {
+		return err
+	}
+	dcFolders, err := dc.Folders(context.TODO())
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Moving datastores out of datastore cluster %s: %v", pod.Value, refs)
+	return moveIntoFolder(c, dcFolders.DatastoreFolder, refs...)
+}
+
+// validateSpaceUtilizationThreshold validates the sdrs_space_utilization_threshold argument of vsphere_datastore_cluster.
+func validateSpaceUtilizationThreshold(v interface{}, k string) (ws []string, errors []error) {
+	if t := v.(int); t < 50 || t > 100 {
+		errors = append(errors, fmt.Errorf("%q must be between 50 and 100", k))
+	}
+	return
+}
+
+// validateIOLatencyThreshold validates the sdrs_io_latency_threshold argument of vsphere_datastore_cluster.
+func validateIOLatencyThreshold(v interface{}, k string) (ws []string, errors []error) {
+	if t := v.(int); t < 5 || t > 100 {
+		errors = append(errors, fmt.Errorf("%q must be between 5 and 100", k))
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereDatastoreCluster_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereDatastoreClusterDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereDatastoreClusterConfig_basic,
+					datacenter,
+					"manual",
+					80,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereDatastoreClusterExists("vsphere_datastore_cluster.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datastore_cluster.foo", "sdrs_automation_level", "manual"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datastore_cluster.foo", "sdrs_space_utilization_threshold", "80"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereDatastoreClusterConfig_basic,
+					datacenter,
+					"automated",
+					70,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereDatastoreClusterExists("vsphere_datastore_cluster.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datastore_cluster.foo", "sdrs_automation_level", "automated"),
+					resource.TestCheckResourceAttr(
+						"vsphere_datastore_cluster.foo", "sdrs_space_utilization_threshold", "70"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_datastore_cluster.foo",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("/%s/datastore/terraform-test", datacenter),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCreateStorageDrsConfigSpec(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereDatastoreCluster().Schema, map[string]interface{}{
+		"sdrs_enabled":                     true,
+		"sdrs_automation_level":            "automated",
+		"sdrs_space_utilization_threshold": 70,
+		"sdrs_io_load_balance_enabled":     false,
+	})
+
+	spec := createStorageDrsConfigSpec(d).PodConfigSpec
+	if !*spec.Enabled || spec.DefaultVmBehavior != "automated" {
+		t.Fatalf("Storage DRS should be enabled and automated: %#v", spec)
+	}
+	if *spec.IoLoadBalanceEnabled {
+		t.Fatal("I/O load balancing should be disabled")
+	}
+	if spec.SpaceLoadBalanceConfig.SpaceUtilizationThreshold != 70 {
+		t.Fatalf("space utilization threshold should be 70: %d", spec.SpaceLoadBalanceConfig.SpaceUtilizationThreshold)
+	}
+	if spec.IoLoadBalanceConfig.IoLatencyThreshold != 15 {
+		t.Fatalf("I/O latency threshold should be the default 15: %d", spec.IoLoadBalanceConfig.IoLatencyThreshold)
+	}
+}
+
+func testAccCheckVSphereDatastoreClusterDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_datastore_cluster" {
+			continue
+		}
+
+		_, err := inventoryPath(client, storagePodReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereDatastoreClusterExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := inventoryPath(client, storagePodReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereDatastoreClusterConfig_basic = `
+resource "vsphere_datastore_cluster" "foo" {
+    name = "terraform-test"
+    datacenter = "%s"
+    sdrs_enabled = true
+    sdrs_automation_level = "%s"
+    sdrs_space_utilization_threshold = %d
+}
+`
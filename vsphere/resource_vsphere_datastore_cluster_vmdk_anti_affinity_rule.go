@@ -0,0 +1,223 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereDatastoreClusterVMDKAntiAffinityRule() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereDatastoreClusterVMDKAntiAffinityRuleCreate,
+		Read:   resourceVSphereDatastoreClusterVMDKAntiAffinityRuleRead,
+		Update: resourceVSphereDatastoreClusterVMDKAntiAffinityRuleUpdate,
+		Delete: resourceVSphereDatastoreClusterVMDKAntiAffinityRuleDelete,
+
+		Schema: map[string]*schema.Schema{
+			"datastore_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"virtual_machine": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"disk_indexes": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 2,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceVSphereDatastoreClusterVMDKAntiAffinityRuleCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pod := storagePodReference(d.Get("datastore_cluster_id").(string))
+
+	vm, err := findDatacenterVirtualMachine(client, pod, d.Get("virtual_machine").(string))
+	if err != nil {
+		return err
+	}
+	msp, err := getStoragePod(client, pod)
+	if err != nil {
+		return err
+	}
+	if info := findStorageDrsVMConfig(msp, vm.Reference()); info != nil && info.IntraVmAntiAffinity != nil {
+		return fmt.Errorf("Virtual machine %s already has a VMDK anti-affinity rule in datastore cluster %s.", vm.Reference().Value, pod.Value)
+	}
+
+	rule, err := createVMDKAntiAffinityRule(d, vm)
+	if err != nil {
+		return err
+	}
+	err = setStorageDrsVMConfig(client, pod, vm.Reference(), func(info *types.StorageDrsVmConfigInfo) {
+		// Disks can't be kept together and apart at the same time.
+		info.IntraVmAffinity = types.NewBool(false)
+		info.IntraVmAntiAffinity = rule
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(clusterObjectID(pod.Value, vm.Reference().Value))
+	log.Printf("[INFO] Created VMDK anti-affinity rule: %s", d.Id())
+
+	return resourceVSphereDatastoreClusterVMDKAntiAffinityRuleRead(d, meta)
+}
+
+func resourceVSphereDatastoreClusterVMDKAntiAffinityRuleRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	msp, err := getStoragePod(client, storagePodReference(podID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datastore cluster not found: %s", podID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}
+	info := findStorageDrsVMConfig(msp, ref)
+	if info == nil || info.IntraVmAntiAffinity == nil {
+		log.Printf("[ERROR] VMDK anti-affinity rule not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+	rule := info.IntraVmAntiAffinity
+
+	// The rule refers to the disks by their device keys, which are converted
+	// to the indexes of the disk blocks of vsphere_virtual_machine.
+	devices, err := object.NewVirtualMachine(client.Client, ref).Device(context.TODO())
+	if err != nil {
+		return err
+	}
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	indexes, missing := vmdkDiskIndexes(disks, rule.DiskId)
+	if len(missing) > 0 {
+		return fmt.Errorf("VMDK anti-affinity rule %s refers to disks %v which virtual machine %s doesn't have.", d.Id(), missing, vmID)
+	}
+
+	paths, err := virtualMachinePaths(client, []types.ManagedObjectReference{ref})
+	if err != nil {
+		return err
+	}
+	d.Set("datastore_cluster_id", podID)
+	d.Set("virtual_machine", paths[0])
+	d.Set("name", rule.Name)
+	d.Set("disk_indexes", indexes)
+	d.Set("enabled", rule.Enabled == nil || *rule.Enabled)
+	return nil
+}
+
+func resourceVSphereDatastoreClusterVMDKAntiAffinityRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	vm := object.NewVirtualMachine(client.Client, types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID})
+	rule, err := createVMDKAntiAffinityRule(d, vm)
+	if err != nil {
+		return err
+	}
+	err = setStorageDrsVMConfig(client, storagePodReference(podID), vm.Reference(), func(info *types.StorageDrsVmConfigInfo) {
+		info.IntraVmAntiAffinity = rule
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVSphereDatastoreClusterVMDKAntiAffinityRuleRead(d, meta)
+}
+
+func resourceVSphereDatastoreClusterVMDKAntiAffinityRuleDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting VMDK anti-affinity rule: %s", d.Id())
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}
+	err = setStorageDrsVMConfig(client, storagePodReference(podID), vm, func(info *types.StorageDrsVmConfigInfo) {
+		info.IntraVmAffinity = nil
+		info.IntraVmAntiAffinity = nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// createVMDKAntiAffinityRule creates VirtualDiskAntiAffinityRuleSpec from the
+// arguments of vsphere_datastore_cluster_vmdk_anti_affinity_rule.
+func createVMDKAntiAffinityRule(d *schema.ResourceData, vm *object.VirtualMachine) (*types.VirtualDiskAntiAffinityRuleSpec, error) {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+
+	rule := &types.VirtualDiskAntiAffinityRuleSpec{
+		ClusterRuleInfo: types.ClusterRuleInfo{
+			Name:    d.Get("name").(string),
+			Enabled: types.NewBool(d.Get("enabled").(bool)),
+		},
+	}
+	for _, v := range d.Get("disk_indexes").([]interface{}) {
+		i := v.(int)
+		if i < 0 || i >= len(disks) {
+			return nil, fmt.Errorf("Hard disk %d not found.", i)
+		}
+		rule.DiskId = append(rule.DiskId, disks[i].GetVirtualDevice().Key)
+	}
+	return rule, nil
+}
+
+// vmdkDiskIndexes converts the device keys of a VMDK anti-affinity rule to the
+// indexes of the disks. The keys which match no disk are returned as missing.
+func vmdkDiskIndexes(disks object.VirtualDeviceList, keys []int) (indexes []int, missing []int) {
+	for _, key := range keys {
+		found := false
+		for i, disk := range disks {
+			if disk.GetVirtualDevice().Key == key {
+				indexes = append(indexes, i)
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+	return indexes, missing
+}
@@ -0,0 +1,26 @@
+package vsphere
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVMDKDiskIndexes(t *testing.T) {
+	var disks object.VirtualDeviceList
+	for _, key := range []int{2000, 2001, 2002} {
+		disks = append(disks, &types.VirtualDisk{VirtualDevice: types.VirtualDevice{Key: key}})
+	}
+
+	indexes, missing := vmdkDiskIndexes(disks, []int{2002, 2000})
+	if !reflect.DeepEqual(indexes, []int{2, 0}) || len(missing) != 0 {
+		t.Fatalf("disks 2002 and 2000 should be indexes 2 and 0: %v %v", indexes, missing)
+	}
+
+	indexes, missing = vmdkDiskIndexes(disks, []int{2001, 2005})
+	if !reflect.DeepEqual(indexes, []int{1}) || !reflect.DeepEqual(missing, []int{2005}) {
+		t.Fatalf("disk 2005 should be missing: %v %v", indexes, missing)
+	}
+}
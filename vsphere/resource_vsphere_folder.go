@@ -0,0 +1,346 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	folderTypeVM         = "vm"
+	folderTypeHost       = "host"
+	folderTypeDatastore  = "datastore"
+	folderTypeNetwork    = "network"
+	folderTypeDatacenter = "datacenter"
+)
+
+func resourceVSphereFolder() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereFolderCreate,
+		Read:   resourceVSphereFolderRead,
+		Update: resourceVSphereFolderUpdate,
+		Delete: resourceVSphereFolderDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereFolderImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     false,
+				ValidateFunc: validateFolderPath,
+			},
+
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      folderTypeVM,
+				ForceNew:     true,
+				ValidateFunc: validateFolderType,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"force_destroy": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceVSphereFolderCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	root, err := getRootFolder(client, d.Get("datacenter").(string), d.Get("type").(string))
+	if err != nil {
+		return err
+	}
+
+	// The missing parent folders are created, but the folder itself must not
+	// exist, or it would be deleted with the resource.
+	p := d.Get("path").(string)
+	parent, err := createFolderPath(client, root, path.Dir(p))
+	if err != nil {
+		return err
+	}
+	folder, err := createFolder(client, parent, path.Base(p))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(folder.Reference().Value)
+	log.Printf("[INFO] Created folder: %s", folder.InventoryPath)
+
+	return resourceVSphereFolderRead(d, meta)
+}
+
+func resourceVSphereFolderRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	p, err := inventoryPath(client, folderReference(d.Id()))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Folder not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	root, err := getRootFolder(client, d.Get("datacenter").(string), d.Get("type").(string))
+	if err != nil {
+		return err
+	}
+	d.Set("path", strings.TrimPrefix(p, strings.TrimSuffix(root.InventoryPath, "/")+"/"))
+	return nil
+}
+
+func resourceVSphereFolderUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	if d.HasChange("path") {
+		oldPath, newPath := d.GetChange("path")
+		ref := folderReference(d.Id())
+
+		if path.Dir(oldPath.(string)) != path.Dir(newPath.(string)) {
+			root, err := getRootFolder(client, d.Get("datacenter").(string), d.Get("type").(string))
+			if err != nil {
+				return err
+			}
+			parent, err := createFolderPath(client, root, path.Dir(newPath.(string)))
+			if err != nil {
+				return err
+			}
+			log.Printf("[INFO] Moving folder %s to %s", d.Id(), parent.InventoryPath)
+			if err := moveIntoFolder(client, parent, ref); err != nil {
+				return err
+			}
+		}
+
+		if path.Base(oldPath.(string)) != path.Base(newPath.(string)) {
+			log.Printf("[INFO] Renaming folder %s to %s", d.Id(), path.Base(newPath.(string)))
+			if err := renameEntity(client, ref, path.Base(newPath.(string))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceVSphereFolderRead(d, meta)
+}
+
+func resourceVSphereFolderDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	folder := object.NewFolder(client.Client, folderReference(d.Id()))
+
+	var mf mo.Folder
+	if err := folder.Properties(context.TODO(), folder.Reference(), []string{"childEntity"}, &mf); err != nil {
+		return err
+	}
+	if len(mf.ChildEntity) > 0 && !d.Get("force_destroy").(bool) {
+		return fmt.Errorf("Folder %s is not empty: set force_destroy to delete it with its %d children.", d.Get("path").(string), len(mf.ChildEntity))
+	}
+
+	log.Printf("[INFO] Deleting folder: %s", d.Id())
+	task, err := folder.Destroy(context.TODO())
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// folderReference returns the reference of a folder by its managed object ID.
+func folderReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "Folder",
+		Value: id,
+	}
+}
+
+// getRootFolder gets the root folder of a folder type. The root folder of
+// datacenter folders is the root folder of the inventory, and the others are
+// the folders of the datacenter.
+func getRootFolder(c *govmomi.Client, datacenter, folderType string) (*object.Folder, error) {
+	if folderType == folderTypeDatacenter {
+		root := object.NewRootFolder(c.Client)
+		root.InventoryPath = "/"
+		return root, nil
+	}
+
+	dc, err := getDatacenter(c, datacenter)
+	if err != nil {
+		return nil, err
+	}
+	dcFolders, err := dc.Folders(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	var root *object.Folder
+	switch folderType {
+	case folderTypeVM:
+		root = dcFolders.VmFolder
+	case folderTypeHost:
+		root = dcFolders.HostFolder
+	case folderTypeDatastore:
+		root = dcFolders.DatastoreFolder
+	case folderTypeNetwork:
+		root = dcFolders.NetworkFolder
+	default:
+		return nil, fmt.Errorf("Invalid folder type: %s", folderType)
+	}
+
+	// The datacenter may be in a folder, so the path is built from its ancestors.
+	p, err := inventoryPath(c, root.Reference())
+	if err != nil {
+		return nil, err
+	}
+	root.InventoryPath = p
+	return root, nil
+}
+
+// resourceVSphereFolderImport imports a folder by its inventory path, such as
+// "/Datacenter/vm/prod/web". The type and the datacenter are taken from the
+// folder.
+func resourceVSphereFolderImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	ref, err := object.NewSearchIndex(client.Client).FindByInventoryPath(context.TODO(), d.Id())
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil || ref.Reference().Type != "Folder" {
+		return nil, fmt.Errorf("Folder %s not found.", d.Id())
+	}
+
+	var mf mo.Folder
+	folder := object.NewFolder(client.Client, ref.Reference())
+	if err := folder.Properties(context.TODO(), folder.Reference(), []string{"childType"}, &mf); err != nil {
+		return nil, err
+	}
+	folderType, err := folderTypeOf(mf.ChildType)
+	if err != nil {
+		return nil, err
+	}
+	if folderType != folderTypeDatacenter {
+		dc, err := getEntityDatacenter(client, folder.Reference())
+		if err != nil {
+			return nil, err
+		}
+		p, err := inventoryPath(client, dc.Reference())
+		if err != nil {
+			return nil, err
+		}
+		d.Set("datacenter", strings.TrimPrefix(p, "/"))
+	}
+	d.Set("type", folderType)
+	d.Set("force_destroy", false)
+
+	d.SetId(folder.Reference().Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// folderTypeOf returns the folder type of a folder by the types of its
+// children.
+func folderTypeOf(childTypes []string) (string, error) {
+	for _, t := range childTypes {
+		switch t {
+		case "VirtualMachine":
+			return folderTypeVM, nil
+		case "ComputeResource":
+			return folderTypeHost, nil
+		case "Datastore":
+			return folderTypeDatastore, nil
+		case "Network":
+			return folderTypeNetwork, nil
+		case "Datacenter":
+			return folderTypeDatacenter, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown folder child types: %v", childTypes)
+}
+
+// createFolder creates a folder in parent. It fails if the folder already
+// exists.
+func createFolder(c *govmomi.Client, parent *object.Folder, name string) (*object.Folder, error) {
+	p := path.Join(parent.InventoryPath, name)
+	_, err := find.NewFinder(c.Client, true).Folder(context.TODO(), p)
+	if err == nil {
+		return nil, fmt.Errorf("Folder %s already exists: import it instead.", p)
+	}
+	if _, ok := err.(*find.NotFoundError); !ok {
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] creating folder: %s", p)
+	folder, err := parent.CreateFolder(context.TODO(), name)
+	if err != nil {
+		return nil, err
+	}
+	folder.InventoryPath = p
+	return folder, nil
+}
+
+// createFolderPath gets the folder at the path relative to root, creating
+// the missing folders in the path.
+func createFolderPath(c *govmomi.Client, root *object.Folder, p string) (*object.Folder, error) {
+	finder := find.NewFinder(c.Client, true)
+
+	folder := root
+	for _, name := range strings.Split(p, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		childPath := path.Join(folder.InventoryPath, name)
+		child, err := finder.Folder(context.TODO(), childPath)
+		if err != nil {
+			if _, ok := err.(*find.NotFoundError); !ok {
+				return nil, err
+			}
+			log.Printf("[DEBUG] creating folder: %s", childPath)
+			child, err = folder.CreateFolder(context.TODO(), name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		child.InventoryPath = childPath
+		folder = child
+	}
+	return folder, nil
+}
+
+// validateFolderType validates the type argument of vsphere_folder.
+func validateFolderType(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case folderTypeVM, folderTypeHost, folderTypeDatastore, folderTypeNetwork, folderTypeDatacenter:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q, %q, %q or %q", k, folderTypeVM, folderTypeHost, folderTypeDatastore, folderTypeNetwork, folderTypeDatacenter))
+	}
+	return
+}
+
+// validateFolderPath validates a folder path relative to the root folder.
+func validateFolderPath(v interface{}, k string) (ws []string, errors []error) {
+	p := v.(string)
+	if p == "" || strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/") || path.Clean(p) != p || strings.HasPrefix(p, "..") {
+		errors = append(errors, fmt.Errorf("%q must be a relative path without leading or trailing slashes, such as \"prod/web\"", k))
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereFolder_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereFolderDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereFolderConfig_basic,
+					datacenter,
+					"terraform-test/foo",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereFolderExists("vsphere_folder.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_folder.foo", "path", "terraform-test/foo"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereFolderConfig_basic,
+					datacenter,
+					"terraform-test/bar/baz",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereFolderExists("vsphere_folder.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_folder.foo", "path", "terraform-test/bar/baz"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_folder.foo",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("/%s/vm/terraform-test/bar/baz", datacenter),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestFolderTypeOf(t *testing.T) {
+	cases := map[string][]string{
+		folderTypeVM:         []string{"Folder", "VirtualMachine", "VirtualApp"},
+		folderTypeHost:       []string{"Folder", "ComputeResource"},
+		folderTypeDatastore:  []string{"Folder", "Datastore", "StoragePod"},
+		folderTypeNetwork:    []string{"Folder", "Network", "DistributedVirtualSwitch"},
+		folderTypeDatacenter: []string{"Folder", "Datacenter"},
+	}
+	for expected, childTypes := range cases {
+		if actual, err := folderTypeOf(childTypes); err != nil || actual != expected {
+			t.Fatalf("%v should be %q: %q, %v", childTypes, expected, actual, err)
+		}
+	}
+
+	if _, err := folderTypeOf([]string{"Folder"}); err == nil {
+		t.Fatal("expected error for unknown child types")
+	}
+}
+
+func TestValidateFolderPath(t *testing.T) {
+	validValues := []string{"prod", "prod/web"}
+	for _, v := range validValues {
+		if _, errors := validateFolderPath(v, "path"); len(errors) != 0 {
+			t.Fatalf("%q should be valid: %q", v, errors)
+		}
+	}
+
+	invalidValues := []string{"", "/prod", "prod/", "prod//web", "../prod"}
+	for _, v := range invalidValues {
+		if _, errors := validateFolderPath(v, "path"); len(errors) == 0 {
+			t.Fatalf("%q should be invalid", v)
+		}
+	}
+}
+
+func testAccCheckVSphereFolderDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_folder" {
+			continue
+		}
+
+		_, err := inventoryPath(client, folderReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereFolderExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := inventoryPath(client, folderReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereFolderConfig_basic = `
+resource "vsphere_folder" "foo" {
+    datacenter = "%s"
+    path = "%s"
+    type = "vm"
+}
+`
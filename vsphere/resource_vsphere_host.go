@@ -0,0 +1,348 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	hostLockdownDisabled = "disabled"
+	hostLockdownNormal   = "normal"
+	hostLockdownStrict   = "strict"
+)
+
+func resourceVSphereHost() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereHostCreate,
+		Read:   resourceVSphereHostRead,
+		Update: resourceVSphereHostUpdate,
+		Delete: resourceVSphereHostDelete,
+
+		Schema: map[string]*schema.Schema{
+			"hostname": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"username": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"password": &schema.Schema{
+				Type:      schema.TypeString,
+				Required:  true,
+				Sensitive: true,
+			},
+
+			"thumbprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"cluster": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"force": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"connected": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"maintenance": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"lockdown": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      hostLockdownDisabled,
+				ValidateFunc: validateHostLockdown,
+			},
+		},
+	}
+}
+
+func resourceVSphereHostCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	spec := createHostConnectSpec(d)
+	connected := d.Get("connected").(bool)
+
+	t, err := addHost(client, d.Get("datacenter").(string), d.Get("cluster").(string), spec, connected)
+	if err != nil {
+		return err
+	}
+	info, err := t.WaitForResult(context.TODO(), nil)
+	if err != nil {
+		return hostConnectError(spec.HostName, err)
+	}
+
+	ref, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return fmt.Errorf("Unexpected result of adding host %s: %#v", spec.HostName, info.Result)
+	}
+	// A standalone host is added with its ComputeResource.
+	if ref.Type != "HostSystem" {
+		hosts, err := object.NewComputeResource(client.Client, ref).Hosts(context.TODO())
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("No host found in %s.", ref.Value)
+		}
+		ref = hosts[0].Reference()
+	}
+
+	d.SetId(ref.Value)
+	log.Printf("[INFO] Added host: %s", d.Id())
+
+	if connected {
+		host := object.NewHostSystem(client.Client, ref)
+		if d.Get("maintenance").(bool) {
+			if err := setHostMaintenance(host, true); err != nil {
+				return err
+			}
+		}
+		if lockdown := d.Get("lockdown").(string); lockdown != hostLockdownDisabled {
+			if err := setHostLockdown(client, host, lockdown); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceVSphereHostRead(d, meta)
+}
+
+func resourceVSphereHostRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	host := object.NewHostSystem(client.Client, hostSystemReference(d.Id()))
+
+	var mh mo.HostSystem
+	if err := host.Properties(context.TODO(), host.Reference(), []string{"runtime", "config"}, &mh); err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Host not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("connected", mh.Runtime.ConnectionState == types.HostSystemConnectionStateConnected)
+	d.Set("maintenance", mh.Runtime.InMaintenanceMode)
+	// The configuration is not available while the host is disconnected.
+	if mh.Config != nil && mh.Config.LockdownMode != "" {
+		switch mh.Config.LockdownMode {
+		case types.HostLockdownModeLockdownNormal:
+			d.Set("lockdown", hostLockdownNormal)
+		case types.HostLockdownModeLockdownStrict:
+			d.Set("lockdown", hostLockdownStrict)
+		default:
+			d.Set("lockdown", hostLockdownDisabled)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereHostUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	host := object.NewHostSystem(client.Client, hostSystemReference(d.Id()))
+	connected := d.Get("connected").(bool)
+
+	if d.HasChange("connected") && connected {
+		spec := createHostConnectSpec(d)
+		log.Printf("[INFO] Reconnecting host: %s", d.Id())
+		t, err := host.Reconnect(context.TODO(), &spec, nil)
+		if err != nil {
+			return err
+		}
+		if err := t.Wait(context.TODO()); err != nil {
+			return hostConnectError(spec.HostName, err)
+		}
+	}
+
+	if connected {
+		if d.HasChange("maintenance") {
+			if err := setHostMaintenance(host, d.Get("maintenance").(bool)); err != nil {
+				return err
+			}
+		}
+		if d.HasChange("lockdown") {
+			if err := setHostLockdown(client, host, d.Get("lockdown").(string)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.HasChange("connected") && !connected {
+		log.Printf("[INFO] Disconnecting host: %s", d.Id())
+		t, err := host.Disconnect(context.TODO())
+		if err := waitForTask(t, err); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereHostRead(d, meta)
+}
+
+func resourceVSphereHostDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	host := object.NewHostSystem(client.Client, hostSystemReference(d.Id()))
+
+	var mh mo.HostSystem
+	if err := host.Properties(context.TODO(), host.Reference(), []string{"runtime", "parent"}, &mh); err != nil {
+		return err
+	}
+
+	if mh.Runtime.ConnectionState != types.HostSystemConnectionStateDisconnected {
+		log.Printf("[INFO] Disconnecting host: %s", d.Id())
+		t, err := host.Disconnect(context.TODO())
+		if err := waitForTask(t, err); err != nil {
+			return err
+		}
+	}
+
+	// A standalone host is removed with its ComputeResource.
+	ref := host.Reference()
+	if mh.Parent != nil && mh.Parent.Type == "ComputeResource" {
+		ref = *mh.Parent
+	}
+	log.Printf("[INFO] Removing host: %s", d.Id())
+	res, err := methods.Destroy_Task(context.TODO(), client.Client, &types.Destroy_Task{This: ref})
+	if err != nil {
+		return err
+	}
+	if err := object.NewTask(client.Client, res.Returnval).Wait(context.TODO()); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// addHost adds a host to the cluster, or as a standalone host to the
+// datacenter if the cluster is empty.
+func addHost(c *govmomi.Client, datacenter, cluster string, spec types.HostConnectSpec, connected bool) (*object.Task, error) {
+	if cluster == "" {
+		folder, err := getRootFolder(c, datacenter, folderTypeHost)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[INFO] Adding standalone host %s to %s", spec.HostName, folder.InventoryPath)
+		return folder.AddStandaloneHost(context.TODO(), spec, connected, nil, nil)
+	}
+
+	dc, err := getDatacenter(c, datacenter)
+	if err != nil {
+		return nil, err
+	}
+	finder := find.NewFinder(c.Client, true)
+	finder = finder.SetDatacenter(dc)
+	cc, err := finder.ClusterComputeResource(context.TODO(), cluster)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[INFO] Adding host %s to cluster %s", spec.HostName, cluster)
+	return cc.AddHost(context.TODO(), spec, connected, nil, nil)
+}
+
+// createHostConnectSpec creates HostConnectSpec from the hostname and credential arguments.
+func createHostConnectSpec(d *schema.ResourceData) types.HostConnectSpec {
+	return types.HostConnectSpec{
+		HostName:      d.Get("hostname").(string),
+		UserName:      d.Get("username").(string),
+		Password:      d.Get("password").(string),
+		SslThumbprint: d.Get("thumbprint").(string),
+		Force:         d.Get("force").(bool),
+	}
+}
+
+// hostConnectError returns an error of connecting to a host, with the
+// thumbprint of the host if its certificate is not verified.
+func hostConnectError(hostname string, err error) error {
+	if e, ok := err.(task.Error); ok {
+		if f, ok := e.Fault().(*types.SSLVerifyFault); ok {
+			return fmt.Errorf("Certificate of host %s is not verified: set thumbprint to %q if you trust it.", hostname, f.Thumbprint)
+		}
+	}
+	return err
+}
+
+// setHostMaintenance enters or exits the maintenance mode of a host. Entering
+// the maintenance mode waits until DRS evacuates the virtual machines of the host.
+func setHostMaintenance(host *object.HostSystem, maintenance bool) error {
+	var t *object.Task
+	var err error
+	if maintenance {
+		log.Printf("[INFO] Entering maintenance mode: %s", host.Reference().Value)
+		t, err = host.EnterMaintenanceMode(context.TODO(), 0, true, nil)
+	} else {
+		log.Printf("[INFO] Exiting maintenance mode: %s", host.Reference().Value)
+		t, err = host.ExitMaintenanceMode(context.TODO(), 0)
+	}
+	return waitForTask(t, err)
+}
+
+// setHostLockdown changes the lockdown mode of a host with HostAccessManager.
+func setHostLockdown(c *govmomi.Client, host *object.HostSystem, lockdown string) error {
+	var mh mo.HostSystem
+	if err := host.Properties(context.TODO(), host.Reference(), []string{"configManager"}, &mh); err != nil {
+		return err
+	}
+	if mh.ConfigManager.HostAccessManager == nil {
+		return fmt.Errorf("Lockdown mode of host %s can't be changed: it requires ESXi 6.0 or later.", host.Reference().Value)
+	}
+
+	mode := types.HostLockdownModeLockdownDisabled
+	switch lockdown {
+	case hostLockdownNormal:
+		mode = types.HostLockdownModeLockdownNormal
+	case hostLockdownStrict:
+		mode = types.HostLockdownModeLockdownStrict
+	}
+
+	log.Printf("[INFO] Changing lockdown mode of host %s: %s", host.Reference().Value, mode)
+	_, err := methods.ChangeLockdownMode(context.TODO(), c.Client, &types.ChangeLockdownMode{
+		This: *mh.ConfigManager.HostAccessManager,
+		Mode: mode,
+	})
+	return err
+}
+
+// validateHostLockdown validates the lockdown argument of vsphere_host.
+func validateHostLockdown(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case hostLockdownDisabled, hostLockdownNormal, hostLockdownStrict:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q or %q", k, hostLockdownDisabled, hostLockdownNormal, hostLockdownStrict))
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccVSphereHost_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	hostname := os.Getenv("VSPHERE_ESXI_HOSTNAME")
+	username := os.Getenv("VSPHERE_ESXI_USER")
+	password := os.Getenv("VSPHERE_ESXI_PASSWORD")
+	thumbprint := os.Getenv("VSPHERE_ESXI_THUMBPRINT")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereHostDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereHostConfig_basic,
+					hostname,
+					username,
+					password,
+					thumbprint,
+					datacenter,
+					cluster,
+					"false",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereHostExists("vsphere_host.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_host.foo", "connected", "true"),
+					resource.TestCheckResourceAttr(
+						"vsphere_host.foo", "maintenance", "false"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereHostConfig_basic,
+					hostname,
+					username,
+					password,
+					thumbprint,
+					datacenter,
+					cluster,
+					"true",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereHostExists("vsphere_host.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_host.foo", "maintenance", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestHostConnectError(t *testing.T) {
+	err := task.Error{
+		LocalizedMethodFault: &types.LocalizedMethodFault{
+			Fault:            &types.SSLVerifyFault{Thumbprint: "AA:BB:CC"},
+			LocalizedMessage: "Authenticity of the host's SSL certificate is not verified.",
+		},
+	}
+	expected := `Certificate of host esxi-1 is not verified: set thumbprint to "AA:BB:CC" if you trust it.`
+	if e := hostConnectError("esxi-1", err); e.Error() != expected {
+		t.Fatalf("error should be %q: %q", expected, e)
+	}
+
+	other := fmt.Errorf("other error")
+	if e := hostConnectError("esxi-1", other); e != other {
+		t.Fatalf("error should be returned as is: %q", e)
+	}
+}
+
+func testAccCheckVSphereHostDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_host" {
+			continue
+		}
+
+		_, err := inventoryPath(client, hostSystemReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereHostExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := inventoryPath(client, hostSystemReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereHostConfig_basic = `
+resource "vsphere_host" "foo" {
+    hostname = "%s"
+    username = "%s"
+    password = "%s"
+    thumbprint = "%s"
+    datacenter = "%s"
+    cluster = "%s"
+    maintenance = %s
+}
+`
@@ -0,0 +1,250 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereNasDatastore() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereNasDatastoreCreate,
+		Read:   resourceVSphereNasDatastoreRead,
+		Update: resourceVSphereNasDatastoreUpdate,
+		Delete: resourceVSphereNasDatastoreDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereNasDatastoreImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"host_system_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.HostFileSystemVolumeFileSystemTypeNFS),
+				ForceNew:     true,
+				ValidateFunc: validateNasType,
+			},
+
+			"remote_hosts": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"remote_path": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"access_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(types.HostMountModeReadWrite),
+				ForceNew:     true,
+				ValidateFunc: validateHostMountMode,
+			},
+
+			"capacity": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"free_space": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVSphereNasDatastoreCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	if d.Get("type").(string) != string(types.HostFileSystemVolumeFileSystemTypeNFS41) && len(d.Get("remote_hosts").([]interface{})) > 1 {
+		return fmt.Errorf("Multiple remote_hosts are supported only by %s.", types.HostFileSystemVolumeFileSystemTypeNFS41)
+	}
+
+	// Mounting the same remote path on each host adds the host to the same datastore.
+	for _, id := range stringSet(d.Get("host_system_ids").(*schema.Set)) {
+		ds, err := mountNasDatastore(client, d, id)
+		if err != nil {
+			return err
+		}
+		if d.Id() == "" {
+			d.SetId(ds.Reference().Value)
+			log.Printf("[INFO] Created NAS datastore: %s", d.Id())
+		}
+	}
+
+	return resourceVSphereNasDatastoreRead(d, meta)
+}
+
+func resourceVSphereNasDatastoreRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	mds, err := getDatastore(client, datastoreReference(d.Id()))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datastore not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	info, ok := mds.Info.(*types.NasDatastoreInfo)
+	if !ok || info.Nas == nil {
+		return fmt.Errorf("Datastore %s is not a NAS datastore.", d.Id())
+	}
+
+	remoteHosts := info.Nas.RemoteHostNames
+	if len(remoteHosts) == 0 {
+		remoteHosts = []string{info.Nas.RemoteHost}
+	}
+	d.Set("name", mds.Name)
+	d.Set("host_system_ids", mountedHostIDs(mds))
+	d.Set("type", info.Nas.Type)
+	d.Set("remote_hosts", remoteHosts)
+	d.Set("remote_path", info.Nas.RemotePath)
+	if len(mds.Host) > 0 {
+		d.Set("access_mode", mds.Host[0].MountInfo.AccessMode)
+	}
+	setDatastoreCapacity(d, mds)
+	return nil
+}
+
+func resourceVSphereNasDatastoreUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ref := datastoreReference(d.Id())
+
+	if d.HasChange("name") {
+		log.Printf("[INFO] Renaming datastore %s to %s", d.Id(), d.Get("name").(string))
+		if err := renameEntity(client, ref, d.Get("name").(string)); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("host_system_ids") {
+		o, n := d.GetChange("host_system_ids")
+		for _, id := range stringSet(n.(*schema.Set).Difference(o.(*schema.Set))) {
+			if _, err := mountNasDatastore(client, d, id); err != nil {
+				return err
+			}
+		}
+		if err := removeDatastore(client, ref, stringSet(o.(*schema.Set).Difference(n.(*schema.Set)))); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereNasDatastoreRead(d, meta)
+}
+
+func resourceVSphereNasDatastoreDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ref := datastoreReference(d.Id())
+
+	mds, err := getDatastore(client, ref)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting NAS datastore: %s", d.Id())
+	var ids []string
+	for _, mount := range mds.Host {
+		ids = append(ids, mount.Key.Value)
+	}
+	if err := removeDatastore(client, ref, ids); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereNasDatastoreImport imports a NAS datastore by its inventory
+// path, such as "/Datacenter/datastore/nfs-1".
+func resourceVSphereNasDatastoreImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	ref, err := findDatastoreByPath(client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(ref.Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// mountNasDatastore mounts the NAS datastore of d on a host.
+func mountNasDatastore(c *govmomi.Client, d *schema.ResourceData, hostID string) (*object.Datastore, error) {
+	hds, err := hostDatastoreSystem(c, hostID)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := createHostNasVolumeSpec(d)
+	log.Printf("[INFO] Mounting NAS datastore %s on host %s: %#v", spec.LocalPath, hostID, spec)
+	return hds.CreateNasDatastore(context.TODO(), spec)
+}
+
+// createHostNasVolumeSpec creates HostNasVolumeSpec from the arguments of vsphere_nas_datastore.
+func createHostNasVolumeSpec(d *schema.ResourceData) types.HostNasVolumeSpec {
+	var remoteHosts []string
+	for _, v := range d.Get("remote_hosts").([]interface{}) {
+		remoteHosts = append(remoteHosts, v.(string))
+	}
+
+	spec := types.HostNasVolumeSpec{
+		RemoteHost: remoteHosts[0],
+		RemotePath: d.Get("remote_path").(string),
+		LocalPath:  d.Get("name").(string),
+		AccessMode: d.Get("access_mode").(string),
+		Type:       d.Get("type").(string),
+	}
+	// Only NFS 4.1 supports multiple remote hosts for multipathing.
+	if spec.Type == string(types.HostFileSystemVolumeFileSystemTypeNFS41) {
+		spec.RemoteHostNames = remoteHosts
+	}
+	return spec
+}
+
+// validateNasType validates the type argument of vsphere_nas_datastore.
+func validateNasType(v interface{}, k string) (ws []string, errors []error) {
+	switch types.HostFileSystemVolumeFileSystemType(v.(string)) {
+	case types.HostFileSystemVolumeFileSystemTypeNFS, types.HostFileSystemVolumeFileSystemTypeNFS41:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q or %q", k, types.HostFileSystemVolumeFileSystemTypeNFS, types.HostFileSystemVolumeFileSystemTypeNFS41))
+	}
+	return
+}
+
+// validateHostMountMode validates the access_mode argument of vsphere_nas_datastore.
+func validateHostMountMode(v interface{}, k string) (ws []string, errors []error) {
+	switch types.HostMountMode(v.(string)) {
+	case types.HostMountModeReadWrite, types.HostMountModeReadOnly:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q or %q", k, types.HostMountModeReadWrite, types.HostMountModeReadOnly))
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereNasDatastore_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	hostID := os.Getenv("VSPHERE_HOST_ID")
+	nfsHost := os.Getenv("VSPHERE_NFS_HOST")
+	nfsPath := os.Getenv("VSPHERE_NFS_PATH")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereNasDatastoreDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereNasDatastoreConfig_basic,
+					"terraform-test",
+					hostID,
+					nfsHost,
+					nfsPath,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereNasDatastoreExists("vsphere_nas_datastore.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_nas_datastore.foo", "host_system_ids.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vsphere_nas_datastore.foo", "access_mode", "readWrite"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereNasDatastoreConfig_basic,
+					"terraform-test-renamed",
+					hostID,
+					nfsHost,
+					nfsPath,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereNasDatastoreExists("vsphere_nas_datastore.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_nas_datastore.foo", "name", "terraform-test-renamed"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_nas_datastore.foo",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("/%s/datastore/terraform-test-renamed", datacenter),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestCreateHostNasVolumeSpec(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereNasDatastore().Schema, map[string]interface{}{
+		"name":         "nfs-1",
+		"remote_hosts": []interface{}{"nfs1.example.com", "nfs2.example.com"},
+		"remote_path":  "/export/nfs-1",
+		"type":         "NFS41",
+	})
+
+	spec := createHostNasVolumeSpec(d)
+	if spec.RemoteHost != "nfs1.example.com" || spec.LocalPath != "nfs-1" || spec.AccessMode != "readWrite" {
+		t.Fatalf("unexpected spec: %#v", spec)
+	}
+	if !reflect.DeepEqual(spec.RemoteHostNames, []string{"nfs1.example.com", "nfs2.example.com"}) {
+		t.Fatalf("NFS 4.1 should have all the remote hosts: %#v", spec.RemoteHostNames)
+	}
+
+	d.Set("type", "NFS")
+	if spec := createHostNasVolumeSpec(d); spec.RemoteHostNames != nil {
+		t.Fatalf("NFS should have no remote host names: %#v", spec.RemoteHostNames)
+	}
+}
+
+func testAccCheckVSphereNasDatastoreDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_nas_datastore" {
+			continue
+		}
+
+		_, err := getDatastore(client, datastoreReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereNasDatastoreExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := getDatastore(client, datastoreReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereNasDatastoreConfig_basic = `
+resource "vsphere_nas_datastore" "foo" {
+    name = "%s"
+    host_system_ids = ["%s"]
+    remote_hosts = ["%s"]
+    remote_path = "%s"
+}
+`
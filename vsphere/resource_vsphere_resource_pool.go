@@ -0,0 +1,292 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereResourcePool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereResourcePoolCreate,
+		Read:   resourceVSphereResourcePoolRead,
+		Update: resourceVSphereResourcePoolUpdate,
+		Delete: resourceVSphereResourcePoolDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereResourcePoolImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"datacenter": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"cluster": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"parent_resource_pool"},
+			},
+
+			"parent_resource_pool": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"cluster"},
+			},
+
+			"cpu_reservation": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+			},
+
+			"cpu_expandable_reservation": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"cpu_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  -1,
+			},
+
+			"cpu_share_level": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  string(types.SharesLevelNormal),
+			},
+
+			"cpu_share_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+
+			"memory_reservation": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+			},
+
+			"memory_expandable_reservation": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"memory_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  -1,
+			},
+
+			"memory_share_level": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  string(types.SharesLevelNormal),
+			},
+
+			"memory_share_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVSphereResourcePoolCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	dc, err := getDatacenter(client, d.Get("datacenter").(string))
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(client.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	parent, err := findResourcePool(finder, d.Get("cluster").(string), d.Get("parent_resource_pool").(string))
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] parent resource pool: %#v", parent)
+
+	spec, err := createResourceConfigSpec(d)
+	if err != nil {
+		return err
+	}
+
+	pool, err := parent.Create(context.TODO(), d.Get("name").(string), *spec)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(pool.Reference().Value)
+	log.Printf("[INFO] Created resource pool: %s", d.Id())
+
+	return resourceVSphereResourcePoolRead(d, meta)
+}
+
+func resourceVSphereResourcePoolRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pool := object.NewResourcePool(client.Client, resourcePoolReference(d.Id()))
+
+	var mrp mo.ResourcePool
+	if err := pool.Properties(context.TODO(), pool.Reference(), []string{"name", "config"}, &mrp); err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Resource pool not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", mrp.Name)
+	for prefix, allocation := range map[string]types.BaseResourceAllocationInfo{
+		"cpu":    mrp.Config.CpuAllocation,
+		"memory": mrp.Config.MemoryAllocation,
+	} {
+		if allocation == nil {
+			continue
+		}
+		info := allocation.GetResourceAllocationInfo()
+		setResourceAllocation(d, prefix, info)
+		if info.ExpandableReservation != nil {
+			d.Set(prefix+"_expandable_reservation", *info.ExpandableReservation)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereResourcePoolUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pool := object.NewResourcePool(client.Client, resourcePoolReference(d.Id()))
+
+	var name string
+	if d.HasChange("name") {
+		name = d.Get("name").(string)
+	}
+
+	spec, err := createResourceConfigSpec(d)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] resource config spec: %#v", spec)
+
+	if err := pool.UpdateConfig(context.TODO(), name, spec); err != nil {
+		return err
+	}
+
+	return resourceVSphereResourcePoolRead(d, meta)
+}
+
+func resourceVSphereResourcePoolDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pool := object.NewResourcePool(client.Client, resourcePoolReference(d.Id()))
+
+	log.Printf("[INFO] Deleting resource pool: %s", d.Id())
+	task, err := pool.Destroy(context.TODO())
+	if err := waitForTask(task, err); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereResourcePoolImport imports a resource pool by its inventory
+// path, such as "/Datacenter/host/Cluster/Resources/pool".
+func resourceVSphereResourcePoolImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	p := d.Id()
+	i := strings.Index(p, "/host/")
+	if !strings.HasPrefix(p, "/") || i < 1 {
+		return nil, fmt.Errorf("Invalid resource pool path %q: it must be like /<datacenter>/host/<cluster>/Resources/<pool>.", p)
+	}
+	datacenter := p[1:i]
+	hostFolder := p[:i] + "/host/"
+
+	finder := find.NewFinder(client.Client, true)
+	pool, err := finder.ResourcePool(context.TODO(), p)
+	if err != nil {
+		return nil, err
+	}
+
+	var mrp mo.ResourcePool
+	if err := pool.Properties(context.TODO(), pool.Reference(), []string{"parent"}, &mrp); err != nil {
+		return nil, err
+	}
+	if mrp.Parent == nil || mrp.Parent.Type != "ResourcePool" {
+		return nil, fmt.Errorf("%s is a root resource pool, which can't be managed.", p)
+	}
+
+	// The parent is the root resource pool of a cluster, or another resource pool.
+	var parent mo.ResourcePool
+	if err := pool.Properties(context.TODO(), *mrp.Parent, []string{"parent"}, &parent); err != nil {
+		return nil, err
+	}
+	if parent.Parent != nil && parent.Parent.Type != "ResourcePool" {
+		cluster, err := inventoryPath(client, *parent.Parent)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("cluster", strings.TrimPrefix(cluster, hostFolder))
+	} else {
+		parentPath, err := inventoryPath(client, *mrp.Parent)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("parent_resource_pool", strings.TrimPrefix(parentPath, hostFolder))
+	}
+
+	d.SetId(pool.Reference().Value)
+	d.Set("datacenter", datacenter)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourcePoolReference returns the reference of a resource pool by its managed object ID.
+func resourcePoolReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "ResourcePool",
+		Value: id,
+	}
+}
+
+// createResourceConfigSpec creates ResourceConfigSpec from the CPU and memory arguments.
+func createResourceConfigSpec(d *schema.ResourceData) (*types.ResourceConfigSpec, error) {
+	cpuAllocation, err := createResourceAllocation(d, "cpu")
+	if err != nil {
+		return nil, err
+	}
+	cpuAllocation.ExpandableReservation = types.NewBool(d.Get("cpu_expandable_reservation").(bool))
+
+	memoryAllocation, err := createResourceAllocation(d, "memory")
+	if err != nil {
+		return nil, err
+	}
+	memoryAllocation.ExpandableReservation = types.NewBool(d.Get("memory_expandable_reservation").(bool))
+
+	return &types.ResourceConfigSpec{
+		CpuAllocation:    cpuAllocation,
+		MemoryAllocation: memoryAllocation,
+	}, nil
+}
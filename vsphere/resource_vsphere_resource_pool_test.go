@@ -0,0 +1,179 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/xml"
+	"golang.org/x/net/context"
+)
+
+func TestAccVSphereResourcePool_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereResourcePoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereResourcePoolConfig_basic,
+					datacenter,
+					cluster,
+					1000,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereResourcePoolExists("vsphere_resource_pool.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_resource_pool.foo", "cpu_reservation", "1000"),
+					resource.TestCheckResourceAttr(
+						"vsphere_resource_pool.bar", "memory_share_level", "high"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereResourcePoolConfig_basic,
+					datacenter,
+					cluster,
+					2000,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereResourcePoolExists("vsphere_resource_pool.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_resource_pool.foo", "cpu_reservation", "2000"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereResourcePoolConfig_basic,
+					datacenter,
+					cluster,
+					0,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereResourcePoolExists("vsphere_resource_pool.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_resource_pool.foo", "cpu_reservation", "0"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_resource_pool.bar",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: testAccVSphereResourcePoolPath("vsphere_resource_pool.bar"),
+			},
+		},
+	})
+}
+
+func TestCreateResourceConfigSpec(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereResourcePool().Schema, map[string]interface{}{
+		"cpu_reservation":            0,
+		"cpu_expandable_reservation": false,
+		"memory_reservation":         1024,
+	})
+
+	spec, err := createResourceConfigSpec(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu := spec.CpuAllocation.GetResourceAllocationInfo()
+	if cpu.Reservation != 0 || *cpu.ExpandableReservation {
+		t.Fatalf("bad cpu allocation: %#v", cpu)
+	}
+	memory := spec.MemoryAllocation.GetResourceAllocationInfo()
+	if memory.Reservation != 1024 || !*memory.ExpandableReservation {
+		t.Fatalf("bad memory allocation: %#v", memory)
+	}
+
+	// The reservation is sent even when it's 0, so it can be reset.
+	b, err := xml.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<reservation>0</reservation>") {
+		t.Fatalf("a reservation of 0 should be sent: %s", b)
+	}
+}
+
+func testAccVSphereResourcePoolPath(n string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return "", fmt.Errorf("Not found: %s", n)
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		return inventoryPath(client, resourcePoolReference(rs.Primary.ID))
+	}
+}
+
+func testAccCheckVSphereResourcePoolDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_resource_pool" {
+			continue
+		}
+
+		pool := object.NewResourcePool(client.Client, resourcePoolReference(rs.Primary.ID))
+		var mrp mo.ResourcePool
+		err := pool.Properties(context.TODO(), pool.Reference(), []string{"name"}, &mrp)
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereResourcePoolExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		pool := object.NewResourcePool(client.Client, resourcePoolReference(rs.Primary.ID))
+		var mrp mo.ResourcePool
+		if err := pool.Properties(context.TODO(), pool.Reference(), []string{"name"}, &mrp); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereResourcePoolConfig_basic = `
+resource "vsphere_resource_pool" "foo" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    cpu_reservation = %d
+    cpu_expandable_reservation = false
+}
+
+resource "vsphere_resource_pool" "bar" {
+    name = "terraform-test-child"
+    datacenter = "${vsphere_resource_pool.foo.datacenter}"
+    parent_resource_pool = "${vsphere_resource_pool.foo.cluster}/Resources/${vsphere_resource_pool.foo.name}"
+    memory_share_level = "high"
+}
+`
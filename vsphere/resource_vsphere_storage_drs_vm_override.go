@@ -0,0 +1,142 @@
+package vsphere
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func resourceVSphereStorageDrsVMOverride() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereStorageDrsVMOverrideCreate,
+		Read:   resourceVSphereStorageDrsVMOverrideRead,
+		Update: resourceVSphereStorageDrsVMOverrideUpdate,
+		Delete: resourceVSphereStorageDrsVMOverrideDelete,
+
+		Schema: map[string]*schema.Schema{
+			"datastore_cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"virtual_machine": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"sdrs_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"sdrs_automation_level": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateStorageDrsBehavior,
+			},
+		},
+	}
+}
+
+func resourceVSphereStorageDrsVMOverrideCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	pod := storagePodReference(d.Get("datastore_cluster_id").(string))
+
+	vm, err := findDatacenterVirtualMachine(client, pod, d.Get("virtual_machine").(string))
+	if err != nil {
+		return err
+	}
+	if err := setStorageDrsVMConfig(client, pod, vm.Reference(), setStorageDrsVMOverride(d)); err != nil {
+		return err
+	}
+
+	d.SetId(clusterObjectID(pod.Value, vm.Reference().Value))
+	log.Printf("[INFO] Created Storage DRS VM override: %s", d.Id())
+
+	return resourceVSphereStorageDrsVMOverrideRead(d, meta)
+}
+
+func resourceVSphereStorageDrsVMOverrideRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	msp, err := getStoragePod(client, storagePodReference(podID))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datastore cluster not found: %s", podID)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}
+	info := findStorageDrsVMConfig(msp, vm)
+	if info == nil || (info.Enabled == nil && info.Behavior == "") {
+		log.Printf("[ERROR] Storage DRS VM override not found: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	paths, err := virtualMachinePaths(client, []types.ManagedObjectReference{vm})
+	if err != nil {
+		return err
+	}
+	d.Set("datastore_cluster_id", podID)
+	d.Set("virtual_machine", paths[0])
+	d.Set("sdrs_enabled", info.Enabled == nil || *info.Enabled)
+	d.Set("sdrs_automation_level", info.Behavior)
+	return nil
+}
+
+func resourceVSphereStorageDrsVMOverrideUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}
+	if err := setStorageDrsVMConfig(client, storagePodReference(podID), vm, setStorageDrsVMOverride(d)); err != nil {
+		return err
+	}
+
+	return resourceVSphereStorageDrsVMOverrideRead(d, meta)
+}
+
+func resourceVSphereStorageDrsVMOverrideDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	podID, vmID, err := parseClusterObjectID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Deleting Storage DRS VM override: %s", d.Id())
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}
+	err = setStorageDrsVMConfig(client, storagePodReference(podID), vm, func(info *types.StorageDrsVmConfigInfo) {
+		info.Enabled = nil
+		info.Behavior = ""
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// setStorageDrsVMOverride returns a function to set the sdrs arguments of
+// vsphere_storage_drs_vm_override to StorageDrsVmConfigInfo.
+func setStorageDrsVMOverride(d *schema.ResourceData) func(*types.StorageDrsVmConfigInfo) {
+	return func(info *types.StorageDrsVmConfigInfo) {
+		info.Enabled = types.NewBool(d.Get("sdrs_enabled").(bool))
+		info.Behavior = d.Get("sdrs_automation_level").(string)
+	}
+}
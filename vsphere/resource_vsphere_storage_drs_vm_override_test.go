@@ -0,0 +1,145 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestAccVSphereStorageDrsVMOverride_basic(t *testing.T) {
+	datastoreClusterID := os.Getenv("VSPHERE_DATASTORE_CLUSTER_ID")
+	vm := os.Getenv("VSPHERE_VM")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereStorageDrsVMOverrideConfig_basic,
+					datastoreClusterID,
+					vm,
+					"true",
+					"automated",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereStorageDrsVMOverrideExists("vsphere_storage_drs_vm_override.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_storage_drs_vm_override.foo", "sdrs_automation_level", "automated"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereStorageDrsVMOverrideConfig_basic,
+					datastoreClusterID,
+					vm,
+					"false",
+					"manual",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereStorageDrsVMOverrideExists("vsphere_storage_drs_vm_override.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_storage_drs_vm_override.foo", "sdrs_enabled", "false"),
+					resource.TestCheckResourceAttr(
+						"vsphere_storage_drs_vm_override.foo", "sdrs_automation_level", "manual"),
+				),
+			},
+		},
+	})
+}
+
+func TestCreateStorageDrsVMConfigSpec(t *testing.T) {
+	vm := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"}
+	rule := &types.VirtualDiskAntiAffinityRuleSpec{DiskId: []int{2000, 2001}}
+
+	cases := []struct {
+		name       string
+		current    *types.StorageDrsVmConfigInfo
+		info       *types.StorageDrsVmConfigInfo
+		operations []types.ArrayUpdateOperation
+	}{
+		{
+			"add",
+			nil,
+			&types.StorageDrsVmConfigInfo{Vm: &vm, Behavior: "manual"},
+			[]types.ArrayUpdateOperation{types.ArrayUpdateOperationAdd},
+		},
+		{
+			"nothing to add",
+			nil,
+			&types.StorageDrsVmConfigInfo{Vm: &vm},
+			nil,
+		},
+		{
+			"edit",
+			&types.StorageDrsVmConfigInfo{Vm: &vm, Behavior: "manual", IntraVmAntiAffinity: rule},
+			&types.StorageDrsVmConfigInfo{Vm: &vm, Behavior: "automated", IntraVmAntiAffinity: rule},
+			[]types.ArrayUpdateOperation{types.ArrayUpdateOperationEdit},
+		},
+		{
+			"clear a field",
+			&types.StorageDrsVmConfigInfo{Vm: &vm, Behavior: "manual", IntraVmAntiAffinity: rule},
+			&types.StorageDrsVmConfigInfo{Vm: &vm, IntraVmAntiAffinity: rule},
+			[]types.ArrayUpdateOperation{types.ArrayUpdateOperationRemove, types.ArrayUpdateOperationAdd},
+		},
+		{
+			"clear all fields",
+			&types.StorageDrsVmConfigInfo{Vm: &vm, Behavior: "manual"},
+			&types.StorageDrsVmConfigInfo{Vm: &vm},
+			[]types.ArrayUpdateOperation{types.ArrayUpdateOperationRemove},
+		},
+	}
+
+	for _, c := range cases {
+		spec := createStorageDrsVMConfigSpec(c.current, c.info)
+		var operations []types.ArrayUpdateOperation
+		for _, v := range spec.VmConfigSpec {
+			operations = append(operations, v.Operation)
+		}
+		if !reflect.DeepEqual(operations, c.operations) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.operations, operations)
+		}
+	}
+}
+
+func testAccCheckVSphereStorageDrsVMOverrideExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		podID, vmID, err := parseClusterObjectID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		msp, err := getStoragePod(client, storagePodReference(podID))
+		if err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+		if findStorageDrsVMConfig(msp, types.ManagedObjectReference{Type: "VirtualMachine", Value: vmID}) == nil {
+			return fmt.Errorf("VM override not found: %s", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereStorageDrsVMOverrideConfig_basic = `
+resource "vsphere_storage_drs_vm_override" "foo" {
+    datastore_cluster_id = "%s"
+    virtual_machine = "%s"
+    sdrs_enabled = %s
+    sdrs_automation_level = "%s"
+}
+`
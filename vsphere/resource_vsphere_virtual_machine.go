@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,49 +16,79 @@ import (
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/progress"
 	"github.com/vmware/govmomi/vim25/types"
 	"golang.org/x/net/context"
 )
 
-var DefaultDNSSuffixes = []string{
-	"vsphere.local",
-}
+const (
+	powerStateOn        = "on"
+	powerStateOff       = "off"
+	powerStateSuspended = "suspended"
 
-var DefaultDNSServers = []string{
-	"8.8.8.8",
-	"8.8.4.4",
-}
+	shutdownGuestTimeout = 5 * time.Minute
+	relocateTimeout      = 60 * time.Minute
+
+	numaVcpuMaxPerVirtualNodeKey = "numa.vcpu.maxPerVirtualNode"
+)
 
 type networkInterface struct {
-	deviceName  string
-	label       string
-	ipAddress   string
-	subnetMask  string
-	adapterType string // TODO: Make "adapter_type" argument
+	deviceName            string
+	label                 string
+	ipAddress             string
+	subnetMask            string
+	additionalIPAddresses []string
+	dnsServers            []string
+	adapterType           string // TODO: Make "adapter_type" argument
 }
 
 type hardDisk struct {
-	size int64
-	iops int64
+	size          int64
+	datastore     string
+	iops          int64
+	ioReservation int
+	ioShareLevel  string
+	ioShareCount  int
 }
 
 type virtualMachine struct {
-	name              string
-	datacenter        string
-	cluster           string
-	resourcePool      string
-	datastore         string
-	vcpu              int
-	memoryMb          int64
-	template          string
-	networkInterfaces []networkInterface
-	hardDisks         []hardDisk
-	gateway           string
-	domain            string
-	timeZone          string
-	dnsSuffixes       []string
-	dnsServers        []string
+	name                string
+	datacenter          string
+	cluster             string
+	resourcePool        string
+	folder              string
+	host                string
+	datastore           string
+	vcpu                int
+	numCoresPerSocket   int
+	numaVcpuMaxPerNode  int
+	memoryMb            int64
+	cpuHotAddEnabled    bool
+	cpuHotRemoveEnabled bool
+	memoryHotAddEnabled bool
+	cpuAllocation       *ResourceAllocationInfo
+	memoryAllocation    *ResourceAllocationInfo
+	memoryLockedToMax   bool
+	extraConfig         map[string]string
+	guestInfo           map[string]string
+	vAppProperties      map[string]string
+	ovfSource           *ovfSource
+	contentLibraryItem  string
+	template            string
+	networkInterfaces   []networkInterface
+	hardDisks           []hardDisk
+	gateway             string
+	domain              string
+	timeZone            string
+	dnsSuffixes         []string
+	dnsServers          []string
+	powerState          string
+
+	// created is set when the VirtualMachine exists, so it's tainted if the
+	// rest of the deployment fails.
+	created bool
 }
 
 func resourceVSphereVirtualMachine() *schema.Resource {
@@ -65,6 +98,12 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 		Update: resourceVSphereVirtualMachineUpdate,
 		Delete: resourceVSphereVirtualMachineDelete,
 
+		CustomizeDiff: resourceVSphereVirtualMachineCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(relocateTimeout),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -78,18 +117,226 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				ForceNew: false,
 			},
 
+			"num_cores_per_socket": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  1,
+			},
+
+			"numa_vcpu_max_per_virtual_node": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+
 			"memory": &schema.Schema{
 				Type:     schema.TypeInt,
 				Required: true,
 				ForceNew: false,
 			},
 
+			"cpu_hot_add_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"cpu_hot_remove_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"memory_hot_add_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"cpu_reservation": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+			},
+
+			"cpu_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  -1,
+			},
+
+			"cpu_share_level": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  string(types.SharesLevelNormal),
+			},
+
+			"cpu_share_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+
+			"memory_reservation": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+			},
+
+			"memory_limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  -1,
+			},
+
+			"memory_share_level": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  string(types.SharesLevelNormal),
+			},
+
+			"memory_share_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Computed: true,
+			},
+
+			"memory_reservation_locked_to_max": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"extra_config": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"cloud_init": &schema.Schema{
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"ignition_config"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"user_data": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateCloudInitUserData,
+						},
+
+						"meta_data": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateCloudInitMetaData,
+						},
+
+						"vendor_data": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateCloudInitUserData,
+						},
+
+						"encoding": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      guestInfoEncodingBase64,
+							ValidateFunc: validateGuestInfoEncoding,
+						},
+					},
+				},
+			},
+
+			"ignition_config": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"cloud_init"},
+				ValidateFunc:  validateIgnitionConfig,
+			},
+
+			"vapp": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"properties": &schema.Schema{
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+
+			"ovf_source": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"local_path": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+
+						"remote_url": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+
+						"network_map": &schema.Schema{
+							Type:     schema.TypeMap,
+							Optional: true,
+							ForceNew: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"disk_provisioning": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      ovfDiskProvisioningThin,
+							ForceNew:     true,
+							ValidateFunc: validateOvfDiskProvisioning,
+						},
+
+						"allow_unverified_ssl": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+
+			"content_library_item": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"ovf_source"},
+			},
+
+			"allow_power_cycle": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
 			"datacenter": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: false,
 			},
 
+			"uuid": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
 			"cluster": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -102,6 +349,18 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				ForceNew: false,
 			},
 
+			"folder": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: false,
+			},
+
+			"host": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
 			"gateway": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -126,14 +385,26 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Type:     schema.TypeList,
 				Optional: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
-				ForceNew: false,
+				ForceNew: true,
 			},
 
 			"dns_server": &schema.Schema{
 				Type:     schema.TypeList,
 				Optional: true,
 				Elem:     &schema.Schema{Type: schema.TypeString},
-				ForceNew: false,
+				ForceNew: true,
+			},
+
+			"guest_dns_suffixes": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"guest_dns_servers": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
 			},
 
 			"network_interface": &schema.Schema{
@@ -161,6 +432,32 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 							ForceNew: false,
 						},
 
+						"additional_ip_addresses": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							ForceNew: true,
+						},
+
+						"ip_addresses": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
+						"dns_server_list": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+							ForceNew: true,
+						},
+
+						"guest_dns_server_list": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+
 						"adapter_type": &schema.Schema{
 							Type:     schema.TypeString,
 							Optional: true,
@@ -198,6 +495,26 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 							Optional: true,
 							ForceNew: false,
 						},
+
+						"io_reservation": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							ForceNew: false,
+						},
+
+						"io_share_level": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  string(types.SharesLevelNormal),
+							ForceNew: false,
+						},
+
+						"io_share_count": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+							ForceNew: false,
+						},
 					},
 				},
 			},
@@ -206,18 +523,81 @@ func resourceVSphereVirtualMachine() *schema.Resource {
 				Type:     schema.TypeInt,
 				Optional: true,
 			},
+
+			"power_state": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     false,
+				ValidateFunc: validatePowerState,
+			},
+
+			"graceful_shutdown": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
 		},
 	}
 }
 
 func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*govmomi.Client)
+	providerClient := meta.(*VSphereClient)
+	client := providerClient.vimClient
 
 	vm := virtualMachine{
-		name:     d.Get("name").(string),
-		vcpu:     d.Get("vcpu").(int),
-		memoryMb: int64(d.Get("memory").(int)),
+		name:                d.Get("name").(string),
+		vcpu:                d.Get("vcpu").(int),
+		numCoresPerSocket:   d.Get("num_cores_per_socket").(int),
+		numaVcpuMaxPerNode:  d.Get("numa_vcpu_max_per_virtual_node").(int),
+		memoryMb:            int64(d.Get("memory").(int)),
+		cpuHotAddEnabled:    d.Get("cpu_hot_add_enabled").(bool),
+		cpuHotRemoveEnabled: d.Get("cpu_hot_remove_enabled").(bool),
+		memoryHotAddEnabled: d.Get("memory_hot_add_enabled").(bool),
+		memoryLockedToMax:   d.Get("memory_reservation_locked_to_max").(bool),
+	}
+
+	if v, ok := d.GetOk("extra_config"); ok {
+		vm.extraConfig = make(map[string]string)
+		for k, v := range v.(map[string]interface{}) {
+			vm.extraConfig[k] = v.(string)
+		}
+	}
+
+	guestInfo, err := createGuestInfo(d.Get("cloud_init").([]interface{}), d.Get("ignition_config").(string))
+	if err != nil {
+		return err
+	}
+	if len(guestInfo) > 0 {
+		vm.guestInfo = make(map[string]string)
+		for k, v := range guestInfo {
+			vm.guestInfo[k] = v.(string)
+		}
+	}
+
+	vm.vAppProperties = getVAppProperties(d.Get("vapp").([]interface{}))
+
+	ovfSource, err := getOvfSource(d)
+	if err != nil {
+		return err
+	}
+	vm.ovfSource = ovfSource
+
+	if v, ok := d.GetOk("content_library_item"); ok {
+		vm.contentLibraryItem = v.(string)
+	}
+
+	cpuAllocation, err := createResourceAllocation(d, "cpu")
+	if err != nil {
+		return err
 	}
+	vm.cpuAllocation = cpuAllocation
+
+	memoryAllocation, err := createResourceAllocation(d, "memory")
+	if err != nil {
+		return err
+	}
+	vm.memoryAllocation = memoryAllocation
 
 	if v, ok := d.GetOk("datacenter"); ok {
 		vm.datacenter = v.(string)
@@ -231,6 +611,14 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		vm.resourcePool = v.(string)
 	}
 
+	if v, ok := d.GetOk("folder"); ok {
+		vm.folder = v.(string)
+	}
+
+	if v, ok := d.GetOk("host"); ok {
+		vm.host = v.(string)
+	}
+
 	if v, ok := d.GetOk("gateway"); ok {
 		vm.gateway = v.(string)
 	}
@@ -243,6 +631,10 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		vm.timeZone = v.(string)
 	}
 
+	if v, ok := d.GetOk("power_state"); ok {
+		vm.powerState = v.(string)
+	}
+
 	dns_suffix := d.Get("dns_suffix.#").(int)
 	if dns_suffix > 0 {
 		vm.dnsSuffixes = make([]string, 0, dns_suffix)
@@ -251,7 +643,7 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 			vm.dnsSuffixes = append(vm.dnsSuffixes, d.Get(s).(string))
 		}
 	} else {
-		vm.dnsSuffixes = DefaultDNSSuffixes
+		vm.dnsSuffixes = providerClient.defaultDNSSuffixes
 	}
 
 	dns_server := d.Get("dns_server.#").(int)
@@ -262,7 +654,7 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 			vm.dnsServers = append(vm.dnsServers, d.Get(s).(string))
 		}
 	} else {
-		vm.dnsServers = DefaultDNSServers
+		vm.dnsServers = providerClient.defaultDNSServers
 	}
 
 	networksCount := d.Get("network_interface.#").(int)
@@ -276,6 +668,16 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 		if v, ok := d.GetOk(prefix + ".subnet_mask"); ok {
 			networks[i].subnetMask = v.(string)
 		}
+		additionalCount := d.Get(prefix + ".additional_ip_addresses.#").(int)
+		for j := 0; j < additionalCount; j++ {
+			s := fmt.Sprintf("%s.additional_ip_addresses.%d", prefix, j)
+			networks[i].additionalIPAddresses = append(networks[i].additionalIPAddresses, d.Get(s).(string))
+		}
+		dnsServerCount := d.Get(prefix + ".dns_server_list.#").(int)
+		for j := 0; j < dnsServerCount; j++ {
+			s := fmt.Sprintf("%s.dns_server_list.%d", prefix, j)
+			networks[i].dnsServers = append(networks[i].dnsServers, d.Get(s).(string))
+		}
 	}
 	vm.networkInterfaces = networks
 	log.Printf("[DEBUG] network_interface init: %v", networks)
@@ -284,10 +686,17 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 	disks := make([]hardDisk, diskCount)
 	for i := 0; i < diskCount; i++ {
 		prefix := fmt.Sprintf("disk.%d", i)
+		disks[i] = getHardDiskIO(d, prefix)
+		if _, err := disks[i].createStorageIOAllocation(); err != nil {
+			return err
+		}
 		if i == 0 {
 			if v, ok := d.GetOk(prefix + ".template"); ok {
+				if vm.ovfSource != nil || vm.contentLibraryItem != "" {
+					return fmt.Errorf("Template argument can't be specified with ovf_source or content_library_item.")
+				}
 				vm.template = v.(string)
-			} else {
+			} else if vm.ovfSource == nil && vm.contentLibraryItem == "" {
 				if v, ok := d.GetOk(prefix + ".size"); ok {
 					disks[i].size = int64(v.(int))
 				} else {
@@ -303,32 +712,52 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 			} else {
 				return fmt.Errorf("Size argument is required.")
 			}
-		}
-		if v, ok := d.GetOk(prefix + ".iops"); ok {
-			disks[i].iops = int64(v.(int))
+			if v, ok := d.GetOk(prefix + ".datastore"); ok {
+				disks[i].datastore = v.(string)
+			}
 		}
 	}
 	vm.hardDisks = disks
 	log.Printf("[DEBUG] disk init: %v", disks)
 
-	if vm.template != "" {
-		err := vm.deployVirtualMachine(client)
-		if err != nil {
-			return fmt.Errorf("error: %s", err)
+	// Cloned and imported virtual machines are powered on and new ones stay powered off
+	// unless power_state is specified.
+	if vm.powerState == "" {
+		if vm.template != "" || vm.ovfSource != nil || vm.contentLibraryItem != "" {
+			vm.powerState = powerStateOn
+		} else {
+			vm.powerState = powerStateOff
 		}
+	}
+
+	if len(vm.vAppProperties) > 0 && vm.template == "" && vm.ovfSource == nil && vm.contentLibraryItem == "" {
+		return fmt.Errorf("vApp properties can be set only when deploying from VM template or OVF.")
+	}
+
+	if vm.contentLibraryItem != "" {
+		err = vm.deployLibraryItem(providerClient)
+	} else if vm.ovfSource != nil {
+		err = vm.importVirtualMachine(client)
+	} else if vm.template != "" {
+		err = vm.deployVirtualMachine(client)
 	} else {
-		err := vm.createVirtualMachine(client)
-		if err != nil {
-			return fmt.Errorf("error: %s", err)
-		}
+		err = vm.createVirtualMachine(client)
+	}
+	// The ID is set as soon as the virtual machine exists, so a virtual
+	// machine which failed to be configured is tainted and replaced.
+	if vm.created {
+		d.SetId(vm.name)
+	}
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
 	}
 
-	if _, ok := d.GetOk("network_interface.0.ip_address"); !ok {
+	if _, ok := d.GetOk("network_interface.0.ip_address"); !ok && vm.powerState != powerStateOff {
 		if v, ok := d.GetOk("boot_delay"); ok {
 			stateConf := &resource.StateChangeConf{
 				Pending:    []string{"pending"},
-				Target:     "active",
-				Refresh:    waitForNetworkingActive(client, vm.datacenter, vm.name),
+				Target:     []string{"active"},
+				Refresh:    waitForNetworkingActive(client, vm.datacenter, vm.folder, vm.name),
 				Timeout:    600 * time.Second,
 				Delay:      time.Duration(v.(int)) * time.Second,
 				MinTimeout: 2 * time.Second,
@@ -340,45 +769,166 @@ func resourceVSphereVirtualMachineCreate(d *schema.ResourceData, meta interface{
 			}
 		}
 	}
-	d.SetId(vm.name)
+
+	if vm.powerState == powerStateSuspended {
+		newVM, err := getVirtualMachine(client, vm.datacenter, vm.folder, vm.name)
+		if err != nil {
+			return err
+		}
+		if err := changePowerState(newVM, powerStateSuspended, false); err != nil {
+			return err
+		}
+	}
 	log.Printf("[INFO] Created virtual machine: %s", d.Id())
 
 	return resourceVSphereVirtualMachineRead(d, meta)
 }
 
-func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*govmomi.Client)
-	dc, err := getDatacenter(client, d.Get("datacenter").(string))
-	if err != nil {
+func resourceVSphereVirtualMachineCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	vcpu := d.Get("vcpu").(int)
+	numCoresPerSocket := d.Get("num_cores_per_socket").(int)
+	if numCoresPerSocket < 1 {
+		return fmt.Errorf("num_cores_per_socket must be greater than 0.")
+	}
+	if vcpu%numCoresPerSocket != 0 {
+		return fmt.Errorf("vcpu (%d) must be a multiple of num_cores_per_socket (%d).", vcpu, numCoresPerSocket)
+	}
+	if d.Id() != "" && d.HasChange("datacenter") {
+		o, n := d.GetChange("datacenter")
+		return fmt.Errorf("datacenter can't be changed from %q to %q: virtual machines can't be migrated to another datacenter or vCenter.", o, n)
+	}
+	if err := validateAdditionalIPAddresses(d.Get("network_interface").([]interface{})); err != nil {
 		return err
 	}
-	finder := find.NewFinder(client.Client, true)
-	finder = finder.SetDatacenter(dc)
-
-	vm, err := finder.VirtualMachine(context.TODO(), d.Get("name").(string))
-	if err != nil {
-		log.Printf("[ERROR] Virtual machine not found: %s", d.Get("name").(string))
-		d.SetId("")
-		return nil
+	if d.Id() == "" {
+		// Guest customization is skipped with cloud-init and Ignition.
+		if len(d.Get("cloud_init").([]interface{})) > 0 || d.Get("ignition_config").(string) != "" {
+			if args := customizationArguments(d.Get); len(args) > 0 {
+				return fmt.Errorf("%s can't be set with cloud_init or ignition_config, which skip guest customization: configure the guest in them instead.", strings.Join(args, ", "))
+			}
+		}
 	}
+	return nil
+}
 
-	var mvm mo.VirtualMachine
-
-	collector := property.DefaultCollector(client.Client)
-	if err := collector.RetrieveOne(context.TODO(), vm.Reference(), []string{"guest", "summary", "datastore"}, &mvm); err != nil {
-		log.Printf("[ERROR] %#v", err)
+// customizationArguments returns the names of the arguments applied by guest
+// customization which are set. domain and time_zone are set when they differ
+// from their defaults.
+func customizationArguments(get func(string) interface{}) []string {
+	var args []string
+	if get("gateway").(string) != "" {
+		args = append(args, "gateway")
 	}
-
-	log.Printf("[DEBUG] %#v", dc)
-	log.Printf("[DEBUG] %#v", mvm.Summary.Config)
-	log.Printf("[DEBUG] %#v", mvm.Guest.Net)
-
-	networkInterfaces := make([]map[string]interface{}, 0)
+	if get("domain").(string) != "vsphere.local" {
+		args = append(args, "domain")
+	}
+	if get("time_zone").(string) != "Etc/UTC" {
+		args = append(args, "time_zone")
+	}
+	for _, k := range []string{"dns_suffix", "dns_server"} {
+		if len(get(k).([]interface{})) > 0 {
+			args = append(args, k)
+		}
+	}
+	for i, v := range get("network_interface").([]interface{}) {
+		nic, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, k := range []string{"ip_address", "subnet_mask"} {
+			if s, _ := nic[k].(string); s != "" {
+				args = append(args, fmt.Sprintf("network_interface.%d.%s", i, k))
+			}
+		}
+		for _, k := range []string{"additional_ip_addresses", "dns_server_list"} {
+			if l, _ := nic[k].([]interface{}); len(l) > 0 {
+				args = append(args, fmt.Sprintf("network_interface.%d.%s", i, k))
+			}
+		}
+	}
+	return args
+}
+
+// validateAdditionalIPAddresses validates that additional_ip_addresses of the
+// network interfaces can be set by guest customization.
+func validateAdditionalIPAddresses(nics []interface{}) error {
+	for i, v := range nics {
+		nic, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addresses, _ := nic["additional_ip_addresses"].([]interface{})
+		var l []string
+		for _, a := range addresses {
+			// Unknown values are empty in the plan.
+			if a, ok := a.(string); ok && a != "" {
+				l = append(l, a)
+			}
+		}
+		if _, err := createIPv6AddressSpec(l); err != nil {
+			return fmt.Errorf("network_interface.%d.additional_ip_addresses: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	dc, err := getDatacenter(client, d.Get("datacenter").(string))
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(client.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	vm, err := findVirtualMachine(client, d, d.Get("folder").(string))
+	if err != nil {
+		return err
+	}
+	if vm == nil {
+		log.Printf("[ERROR] Virtual machine not found: %s", d.Get("name").(string))
+		d.SetId("")
+		return nil
+	}
+
+	var mvm mo.VirtualMachine
+
+	collector := property.DefaultCollector(client.Client)
+	if err := collector.RetrieveOne(context.TODO(), vm.Reference(), []string{"guest", "summary", "datastore", "runtime", "config"}, &mvm); err != nil {
+		log.Printf("[ERROR] %#v", err)
+	}
+	if mvm.Config != nil {
+		d.Set("uuid", mvm.Config.InstanceUuid)
+	}
+
+	paths, err := virtualMachinePaths(client, []types.ManagedObjectReference{vm.Reference()})
+	if err != nil {
+		return err
+	}
+	if folder := path.Dir(paths[0]); folder != "." {
+		d.Set("folder", folder)
+	} else {
+		d.Set("folder", "")
+	}
+
+	if mvm.Runtime.Host != nil {
+		host, err := hostPath(client, finder, *mvm.Runtime.Host, d.Get("host").(string))
+		if err != nil {
+			return err
+		}
+		d.Set("host", host)
+	}
+
+	log.Printf("[DEBUG] %#v", dc)
+	log.Printf("[DEBUG] %#v", mvm.Summary.Config)
+	log.Printf("[DEBUG] %#v", mvm.Guest.Net)
+
+	networkInterfaces := make([]map[string]interface{}, 0)
 	for _, v := range mvm.Guest.Net {
 		if v.DeviceConfigId >= 0 {
 			log.Printf("[DEBUG] %#v", v.Network)
 			networkInterface := make(map[string]interface{})
-			networkInterface["label"] = v.Network
+			networkInterface["label"] = networkLabel(client, finder, d.Get(fmt.Sprintf("network_interface.%d.label", len(networkInterfaces))).(string), v.Network)
 			if len(v.IpAddress) > 0 {
 				log.Printf("[DEBUG] %#v", v.IpAddress[0])
 				networkInterface["ip_address"] = v.IpAddress[0]
@@ -388,10 +938,31 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 				networkInterface["subnet_mask"] = subnetMask.String()
 				log.Printf("[DEBUG] %#v", subnetMask.String())
 			}
+			// Only guest customization applies additional_ip_addresses and
+			// dns_server_list, so the configured values are kept, and the
+			// guest values are read into ip_addresses and guest_dns_server_list.
+			prefix := fmt.Sprintf("network_interface.%d", len(networkInterfaces))
+			networkInterface["ip_addresses"] = v.IpAddress
+			networkInterface["additional_ip_addresses"] = d.Get(prefix + ".additional_ip_addresses")
+			networkInterface["dns_server_list"] = d.Get(prefix + ".dns_server_list")
+			if v.DnsConfig != nil {
+				networkInterface["guest_dns_server_list"] = v.DnsConfig.IpAddress
+			}
 			networkInterfaces = append(networkInterfaces, networkInterface)
 		}
 	}
-	d.Set("network_interface", networkInterfaces)
+	// The guest reports no network interfaces while the virtual machine is
+	// powered off or VMware Tools is not running.
+	if len(networkInterfaces) > 0 {
+		d.Set("network_interface", networkInterfaces)
+	}
+
+	if len(mvm.Guest.IpStack) > 0 && mvm.Guest.IpStack[0].DnsConfig != nil {
+		dnsConfig := mvm.Guest.IpStack[0].DnsConfig
+		log.Printf("[DEBUG] %#v", dnsConfig)
+		d.Set("guest_dns_servers", dnsConfig.IpAddress)
+		d.Set("guest_dns_suffixes", dnsConfig.SearchDomain)
+	}
 
 	var rootDatastore string
 	for _, v := range mvm.Datastore {
@@ -415,109 +986,807 @@ func resourceVSphereVirtualMachineRead(d *schema.ResourceData, meta interface{})
 
 	d.Set("datacenter", dc)
 	d.Set("memory", mvm.Summary.Config.MemorySizeMB)
-	d.Set("cpu", mvm.Summary.Config.NumCpu)
+	d.Set("vcpu", mvm.Summary.Config.NumCpu)
 	d.Set("datastore", rootDatastore)
 
+	d.Set("power_state", powerStateString(mvm.Runtime.PowerState))
+
+	if mvm.Config != nil {
+		devices := object.VirtualDeviceList(mvm.Config.Hardware.Device)
+		disks := d.Get("disk").([]interface{})
+		for i, device := range devices.SelectByType((*types.VirtualDisk)(nil)) {
+			if i >= len(disks) {
+				break
+			}
+			allocation := device.(*types.VirtualDisk).StorageIOAllocation
+			if allocation == nil {
+				continue
+			}
+			disk := disks[i].(map[string]interface{})
+			disk["iops"] = 0
+			if allocation.Limit > 0 {
+				disk["iops"] = allocation.Limit
+			}
+			disk["io_reservation"] = allocation.Reservation
+			if allocation.Shares != nil {
+				disk["io_share_level"] = string(allocation.Shares.Level)
+				disk["io_share_count"] = allocation.Shares.Shares
+			}
+		}
+		d.Set("disk", disks)
+	}
+
+	if mvm.Config != nil {
+		d.Set("cpu_hot_add_enabled", mvm.Config.CpuHotAddEnabled != nil && *mvm.Config.CpuHotAddEnabled)
+		d.Set("cpu_hot_remove_enabled", mvm.Config.CpuHotRemoveEnabled != nil && *mvm.Config.CpuHotRemoveEnabled)
+		d.Set("memory_hot_add_enabled", mvm.Config.MemoryHotAddEnabled != nil && *mvm.Config.MemoryHotAddEnabled)
+		d.Set("num_cores_per_socket", mvm.Config.Hardware.NumCoresPerSocket)
+		d.Set("memory_reservation_locked_to_max", mvm.Config.MemoryReservationLockedToMax != nil && *mvm.Config.MemoryReservationLockedToMax)
+		if mvm.Config.CpuAllocation != nil {
+			setResourceAllocation(d, "cpu", mvm.Config.CpuAllocation.GetResourceAllocationInfo())
+		}
+		if mvm.Config.MemoryAllocation != nil {
+			setResourceAllocation(d, "memory", mvm.Config.MemoryAllocation.GetResourceAllocationInfo())
+		}
+
+		// Only the keys managed by Terraform are read back from extra config.
+		managedExtraConfig := d.Get("extra_config").(map[string]interface{})
+		extraConfig := make(map[string]interface{})
+		d.Set("numa_vcpu_max_per_virtual_node", 0)
+		for _, v := range mvm.Config.ExtraConfig {
+			option := v.GetOptionValue()
+			if option.Key == numaVcpuMaxPerVirtualNodeKey {
+				if n, err := strconv.Atoi(fmt.Sprint(option.Value)); err == nil {
+					d.Set("numa_vcpu_max_per_virtual_node", n)
+				}
+			}
+			if _, ok := managedExtraConfig[option.Key]; ok {
+				extraConfig[option.Key] = fmt.Sprint(option.Value)
+			}
+		}
+		d.Set("extra_config", extraConfig)
+
+		// Only the vApp properties managed by Terraform are read back.
+		managedVAppProperties := getVAppProperties(d.Get("vapp").([]interface{}))
+		if len(managedVAppProperties) > 0 && mvm.Config.VAppConfig != nil {
+			properties := make(map[string]interface{})
+			for _, p := range mvm.Config.VAppConfig.GetVmConfigInfo().Property {
+				if _, ok := managedVAppProperties[p.Id]; !ok {
+					continue
+				}
+				if p.Value != "" {
+					properties[p.Id] = p.Value
+				} else {
+					properties[p.Id] = p.DefaultValue
+				}
+			}
+			d.Set("vapp", []interface{}{
+				map[string]interface{}{
+					"properties": properties,
+				},
+			})
+		}
+	}
+
 	// Initialize the connection info
-	d.SetConnInfo(map[string]string{
-		"type": "ssh",
-		"host": networkInterfaces[0]["ip_address"].(string),
-	})
+	if len(networkInterfaces) > 0 {
+		if ip, ok := networkInterfaces[0]["ip_address"].(string); ok {
+			d.SetConnInfo(map[string]string{
+				"type": "ssh",
+				"host": ip,
+			})
+		}
+	}
 
 	return nil
 }
 
 func resourceVSphereVirtualMachineUpdate(d *schema.ResourceData, meta interface{}) error {
-	return nil
+	client := meta.(*VSphereClient).vimClient
+	// The virtual machine is still in the old folder if folder is changed.
+	oldFolder, newFolder := d.GetChange("folder")
+	vm, err := findVirtualMachine(client, d, oldFolder.(string))
+	if err != nil {
+		return err
+	}
+	if vm == nil {
+		return fmt.Errorf("Virtual machine %s not found.", d.Id())
+	}
+
+	// vApp properties are checked before the virtual machine is changed.
+	var vAppConfig *types.VmConfigSpec
+	if d.HasChange("vapp") {
+		o, n := d.GetChange("vapp")
+		oldProperties := getVAppProperties(o.([]interface{}))
+		newProperties := getVAppProperties(n.([]interface{}))
+		var removed []string
+		for k := range oldProperties {
+			if _, ok := newProperties[k]; !ok {
+				removed = append(removed, k)
+			}
+		}
+		vAppConfig, err = createVAppConfigSpec(client, vm, newProperties, removed)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("folder") {
+		dc, err := getDatacenter(client, d.Get("datacenter").(string))
+		if err != nil {
+			return err
+		}
+		folder, err := getVMFolder(client, dc, newFolder.(string))
+		if err != nil {
+			return err
+		}
+		log.Printf("[INFO] Moving virtual machine %s to folder %s", d.Id(), folder.InventoryPath)
+		if err := moveIntoFolder(client, folder, vm.Reference()); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("cluster") || d.HasChange("resource_pool") || d.HasChange("host") || hasDiskDatastoreChange(d) {
+		if err := relocateVirtualMachine(client, d, vm); err != nil {
+			return err
+		}
+	}
+
+	graceful := d.Get("graceful_shutdown").(bool)
+	powerState := d.Get("power_state").(string)
+	if d.HasChange("power_state") {
+		// Power off first, so that the following reconfiguration doesn't need a power cycle.
+		if powerState == powerStateOff {
+			if err := changePowerState(vm, powerState, graceful); err != nil {
+				return err
+			}
+		}
+	}
+
+	configSpec := types.VirtualMachineConfigSpec{}
+	changed := false
+	needsPowerOff := false
+
+	if d.HasChange("vcpu") {
+		o, n := d.GetChange("vcpu")
+		configSpec.NumCPUs = n.(int)
+		oldHotAdd, _ := d.GetChange("cpu_hot_add_enabled")
+		oldHotRemove, _ := d.GetChange("cpu_hot_remove_enabled")
+		if (n.(int) > o.(int) && !oldHotAdd.(bool)) || (n.(int) < o.(int) && !oldHotRemove.(bool)) {
+			needsPowerOff = true
+		}
+		changed = true
+	}
+
+	if d.HasChange("num_cores_per_socket") {
+		configSpec.NumCoresPerSocket = d.Get("num_cores_per_socket").(int)
+		needsPowerOff = true
+		changed = true
+	}
+
+	if d.HasChange("numa_vcpu_max_per_virtual_node") {
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, numaOptionValue(d.Get("numa_vcpu_max_per_virtual_node").(int)))
+		needsPowerOff = true
+		changed = true
+	}
+
+	if d.HasChange("extra_config") {
+		o, n := d.GetChange("extra_config")
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, createExtraConfigChange(o.(map[string]interface{}), n.(map[string]interface{}))...)
+		changed = true
+	}
+
+	if d.HasChange("cloud_init") || d.HasChange("ignition_config") {
+		oldCloudInit, newCloudInit := d.GetChange("cloud_init")
+		oldIgnition, newIgnition := d.GetChange("ignition_config")
+		oldGuestInfo, err := createGuestInfo(oldCloudInit.([]interface{}), oldIgnition.(string))
+		if err != nil {
+			return err
+		}
+		newGuestInfo, err := createGuestInfo(newCloudInit.([]interface{}), newIgnition.(string))
+		if err != nil {
+			return err
+		}
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, createExtraConfigChange(oldGuestInfo, newGuestInfo)...)
+		changed = true
+	}
+
+	if vAppConfig != nil {
+		configSpec.VAppConfig = vAppConfig
+		changed = true
+	}
+
+	if d.HasChange("memory") {
+		o, n := d.GetChange("memory")
+		configSpec.MemoryMB = int64(n.(int))
+		oldHotAdd, _ := d.GetChange("memory_hot_add_enabled")
+		if n.(int) < o.(int) || !oldHotAdd.(bool) {
+			needsPowerOff = true
+		}
+		changed = true
+	}
+
+	if d.HasChange("cpu_reservation") || d.HasChange("cpu_limit") || d.HasChange("cpu_share_level") || d.HasChange("cpu_share_count") {
+		cpuAllocation, err := createResourceAllocation(d, "cpu")
+		if err != nil {
+			return err
+		}
+		configSpec.CpuAllocation = cpuAllocation
+		changed = true
+	}
+
+	if d.HasChange("memory_reservation") || d.HasChange("memory_limit") || d.HasChange("memory_share_level") || d.HasChange("memory_share_count") {
+		memoryAllocation, err := createResourceAllocation(d, "memory")
+		if err != nil {
+			return err
+		}
+		configSpec.MemoryAllocation = memoryAllocation
+		changed = true
+	}
+
+	if d.HasChange("memory_reservation_locked_to_max") {
+		configSpec.MemoryReservationLockedToMax = types.NewBool(d.Get("memory_reservation_locked_to_max").(bool))
+		changed = true
+	}
+
+	if d.HasChange("cpu_hot_add_enabled") || d.HasChange("cpu_hot_remove_enabled") || d.HasChange("memory_hot_add_enabled") {
+		configSpec.CpuHotAddEnabled = types.NewBool(d.Get("cpu_hot_add_enabled").(bool))
+		configSpec.CpuHotRemoveEnabled = types.NewBool(d.Get("cpu_hot_remove_enabled").(bool))
+		configSpec.MemoryHotAddEnabled = types.NewBool(d.Get("memory_hot_add_enabled").(bool))
+		needsPowerOff = true
+		changed = true
+	}
+
+	for i := 0; i < d.Get("disk.#").(int); i++ {
+		prefix := fmt.Sprintf("disk.%d", i)
+		if d.HasChange(prefix+".iops") || d.HasChange(prefix+".io_reservation") || d.HasChange(prefix+".io_share_level") || d.HasChange(prefix+".io_share_count") {
+			if err := setHardDiskIO(vm, i, getHardDiskIO(d, prefix)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if changed {
+		err = reconfigureVirtualMachine(vm, configSpec, needsPowerOff, d.Get("allow_power_cycle").(bool), graceful)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("power_state") && powerState != powerStateOff {
+		if err := changePowerState(vm, powerState, graceful); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereVirtualMachineRead(d, meta)
 }
 
 func resourceVSphereVirtualMachineDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*govmomi.Client)
-	dc, err := getDatacenter(client, d.Get("datacenter").(string))
+	client := meta.(*VSphereClient).vimClient
+	vm, err := findVirtualMachine(client, d, d.Get("folder").(string))
 	if err != nil {
 		return err
 	}
-	finder := find.NewFinder(client.Client, true)
+	if vm == nil {
+		return fmt.Errorf("Virtual machine %s not found.", d.Id())
+	}
+
+	log.Printf("[INFO] Deleting virtual machine: %s", d.Id())
+
+	err = changePowerState(vm, powerStateOff, false)
+	if err != nil {
+		return err
+	}
+
+	task, err := vm.Destroy(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	err = task.Wait(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func waitForNetworkingActive(client *govmomi.Client, datacenter, folder, name string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		vm, err := getVirtualMachine(client, datacenter, folder, name)
+		if err != nil {
+			log.Printf("[ERROR] %#v", err)
+			return nil, "", err
+		}
+
+		var mvm mo.VirtualMachine
+		collector := property.DefaultCollector(client.Client)
+		if err := collector.RetrieveOne(context.TODO(), vm.Reference(), []string{"summary"}, &mvm); err != nil {
+			log.Printf("[ERROR] %#v", err)
+			return nil, "", err
+		}
+
+		if mvm.Summary.Guest.IpAddress != "" {
+			log.Printf("[DEBUG] IP address with DHCP: %v", mvm.Summary.Guest.IpAddress)
+			return mvm.Summary, "active", err
+		} else {
+			log.Printf("[DEBUG] Waiting for IP address")
+			return nil, "pending", err
+		}
+	}
+}
+
+// getDatacenter gets datacenter object
+func getDatacenter(c *govmomi.Client, dc string) (*object.Datacenter, error) {
+	finder := find.NewFinder(c.Client, true)
+	if dc != "" {
+		d, err := finder.Datacenter(context.TODO(), dc)
+		return d, err
+	} else {
+		d, err := finder.DefaultDatacenter(context.TODO())
+		return d, err
+	}
+}
+
+// getVirtualMachine gets virtual machine object in the folder relative to the VM folder of the datacenter
+func getVirtualMachine(c *govmomi.Client, datacenter, folder, name string) (*object.VirtualMachine, error) {
+	dc, err := getDatacenter(c, datacenter)
+	if err != nil {
+		return nil, err
+	}
+	finder := find.NewFinder(c.Client, true)
+	finder = finder.SetDatacenter(dc)
+
+	return finder.VirtualMachine(context.TODO(), path.Join(folder, name))
+}
+
+// findVirtualMachine finds the virtual machine of the resource by its instance
+// UUID, so that it's found even if it was moved outside of Terraform. Without
+// uuid in the state, it's found by name in the folder. It returns nil if the
+// virtual machine doesn't exist.
+func findVirtualMachine(c *govmomi.Client, d *schema.ResourceData, folder string) (*object.VirtualMachine, error) {
+	uuid := d.Get("uuid").(string)
+	if uuid == "" {
+		vm, err := getVirtualMachine(c, d.Get("datacenter").(string), folder, d.Get("name").(string))
+		if _, ok := err.(*find.NotFoundError); ok {
+			return nil, nil
+		}
+		return vm, err
+	}
+
+	dc, err := getDatacenter(c, d.Get("datacenter").(string))
+	if err != nil {
+		return nil, err
+	}
+	si := object.NewSearchIndex(c.Client)
+	ref, err := si.FindByUuid(context.TODO(), dc, uuid, true, types.NewBool(true))
+	if err != nil || ref == nil {
+		return nil, err
+	}
+	return object.NewVirtualMachine(c.Client, ref.Reference()), nil
+}
+
+// getVMFolder gets the folder relative to the VM folder of the datacenter.
+// If folder is empty, it returns the VM folder itself.
+func getVMFolder(c *govmomi.Client, dc *object.Datacenter, folder string) (*object.Folder, error) {
+	dcFolders, err := dc.Folders(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	if folder == "" {
+		return dcFolders.VmFolder, nil
+	}
+
+	finder := find.NewFinder(c.Client, true)
 	finder = finder.SetDatacenter(dc)
+	return finder.Folder(context.TODO(), path.Join(dcFolders.VmFolder.InventoryPath, folder))
+}
 
-	vm, err := finder.VirtualMachine(context.TODO(), d.Get("name").(string))
+// moveIntoFolder moves the managed entities into the folder.
+func moveIntoFolder(c *govmomi.Client, folder *object.Folder, refs ...types.ManagedObjectReference) error {
+	req := types.MoveIntoFolder_Task{
+		This: folder.Reference(),
+		List: refs,
+	}
+	res, err := methods.MoveIntoFolder_Task(context.TODO(), c.Client, &req)
 	if err != nil {
 		return err
 	}
+	return object.NewTask(c.Client, res.Returnval).Wait(context.TODO())
+}
 
-	log.Printf("[INFO] Deleting virtual machine: %s", d.Id())
+// validatePowerState validates the power_state argument of vsphere_virtual_machine.
+func validatePowerState(v interface{}, k string) (ws []string, errors []error) {
+	switch v.(string) {
+	case powerStateOn, powerStateOff, powerStateSuspended:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q, %q or %q", k, powerStateOn, powerStateOff, powerStateSuspended))
+	}
+	return
+}
+
+// changePowerState powers on, shuts down, powers off or suspends the VirtualMachine.
+// If graceful is true, the guest is shut down before it is powered off.
+func changePowerState(vm *object.VirtualMachine, powerState string, graceful bool) error {
+	current, err := vm.PowerState(context.TODO())
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] power state: %s -> %s", current, powerState)
+
+	switch powerState {
+	case powerStateOn:
+		if current == types.VirtualMachinePowerStatePoweredOn {
+			return nil
+		}
+		return waitForTask(vm.PowerOn(context.TODO()))
+	case powerStateOff:
+		if current == types.VirtualMachinePowerStatePoweredOff {
+			return nil
+		}
+		if graceful && current == types.VirtualMachinePowerStatePoweredOn {
+			err := shutdownGuest(vm)
+			if err == nil {
+				return nil
+			}
+			log.Printf("[WARN] Failed to shut down guest, powering off: %s", err)
+		}
+		return waitForTask(vm.PowerOff(context.TODO()))
+	case powerStateSuspended:
+		if current == types.VirtualMachinePowerStateSuspended {
+			return nil
+		}
+		if current == types.VirtualMachinePowerStatePoweredOff {
+			if err := waitForTask(vm.PowerOn(context.TODO())); err != nil {
+				return err
+			}
+		}
+		return waitForTask(vm.Suspend(context.TODO()))
+	}
+	return fmt.Errorf("Invalid power state %q.", powerState)
+}
+
+// powerStateString converts VirtualMachinePowerState to the value of power_state argument.
+func powerStateString(powerState types.VirtualMachinePowerState) string {
+	switch powerState {
+	case types.VirtualMachinePowerStatePoweredOn:
+		return powerStateOn
+	case types.VirtualMachinePowerStateSuspended:
+		return powerStateSuspended
+	}
+	return powerStateOff
+}
 
-	task, err := vm.PowerOff(context.TODO())
+// reconfigureVirtualMachine reconfigures the VirtualMachine. If the change can't be
+// applied while the VirtualMachine is running, it is power cycled when allowPowerCycle is true.
+func reconfigureVirtualMachine(vm *object.VirtualMachine, configSpec types.VirtualMachineConfigSpec, needsPowerOff, allowPowerCycle, graceful bool) error {
+	current, err := vm.PowerState(context.TODO())
 	if err != nil {
 		return err
 	}
 
-	err = task.Wait(context.TODO())
-	if err != nil {
-		return err
+	powerCycle := needsPowerOff && current != types.VirtualMachinePowerStatePoweredOff
+	if powerCycle {
+		if !allowPowerCycle {
+			return fmt.Errorf("The change requires powering off the virtual machine. Set allow_power_cycle to apply it.")
+		}
+		log.Printf("[INFO] Powering off virtual machine to reconfigure: %s", vm)
+		if err := changePowerState(vm, powerStateOff, graceful); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[DEBUG] reconfigure spec: %#v", configSpec)
+	if err := waitForTask(vm.Reconfigure(context.TODO(), configSpec)); err != nil {
+		return err
+	}
+
+	if powerCycle {
+		log.Printf("[INFO] Restoring power state of virtual machine: %s", vm)
+		return changePowerState(vm, powerStateString(current), false)
+	}
+	return nil
+}
+
+// shutdownGuest shuts down the guest and waits until the VirtualMachine is powered off.
+func shutdownGuest(vm *object.VirtualMachine) error {
+	running, err := vm.IsToolsRunning(context.TODO())
+	if err != nil {
+		return err
+	}
+	if !running {
+		return fmt.Errorf("VMware Tools is not running")
+	}
+
+	if err := vm.ShutdownGuest(context.TODO()); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), shutdownGuestTimeout)
+	defer cancel()
+	return vm.WaitForPowerState(ctx, types.VirtualMachinePowerStatePoweredOff)
+}
+
+// relocateVirtualMachine migrates a VirtualMachine to the resource pool, host
+// and datastore changed in d with vMotion and/or Storage vMotion. The targets
+// must be in the datacenter of the VirtualMachine.
+func relocateVirtualMachine(c *govmomi.Client, d *schema.ResourceData, vm *object.VirtualMachine) error {
+	dc, err := getEntityDatacenter(c, vm.Reference())
+	if err != nil {
+		return err
+	}
+	finder := find.NewFinder(c.Client, true)
+	finder = finder.SetDatacenter(dc)
+	dcFolders, err := dc.Folders(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	spec := types.VirtualMachineRelocateSpec{}
+	var targets []types.ManagedObjectReference
+	if d.HasChange("cluster") || d.HasChange("resource_pool") {
+		pool, err := findResourcePool(finder, d.Get("cluster").(string), d.Get("resource_pool").(string))
+		if err != nil {
+			return err
+		}
+		ref := pool.Reference()
+		spec.Pool = &ref
+		targets = append(targets, ref)
+	}
+	if host := d.Get("host").(string); d.HasChange("host") && host != "" {
+		h, err := finder.HostSystem(context.TODO(), host)
+		if err != nil {
+			return err
+		}
+		ref := h.Reference()
+		spec.Host = &ref
+		targets = append(targets, ref)
+	}
+	if datastore := d.Get("disk.0.datastore").(string); d.HasChange("disk.0.datastore") && datastore != "" {
+		ds, err := findMigrationDatastore(c, finder, dcFolders, datastore)
+		if err != nil {
+			return err
+		}
+		ref := ds.Reference()
+		spec.Datastore = &ref
+		targets = append(targets, ref)
+	}
+	// Disks with their own datastore are kept there when the virtual machine
+	// is moved to another datastore.
+	var disks object.VirtualDeviceList
+	for i := 1; i < d.Get("disk.#").(int); i++ {
+		prefix := fmt.Sprintf("disk.%d.datastore", i)
+		datastore := d.Get(prefix).(string)
+		if datastore == "" || (spec.Datastore == nil && !d.HasChange(prefix)) {
+			continue
+		}
+		if disks == nil {
+			devices, err := vm.Device(context.TODO())
+			if err != nil {
+				return err
+			}
+			disks = devices.SelectByType((*types.VirtualDisk)(nil))
+		}
+		if i >= len(disks) {
+			return fmt.Errorf("Hard disk %d not found.", i)
+		}
+		var ref types.ManagedObjectReference
+		if d.HasChange(prefix) {
+			ds, err := findMigrationDatastore(c, finder, dcFolders, datastore)
+			if err != nil {
+				return err
+			}
+			ref = ds.Reference()
+		} else {
+			// The disk may be placed by Storage DRS, so it stays on its
+			// current datastore.
+			backing, ok := disks[i].(*types.VirtualDisk).Backing.(types.BaseVirtualDeviceFileBackingInfo)
+			if !ok || backing.GetVirtualDeviceFileBackingInfo().Datastore == nil {
+				return fmt.Errorf("Datastore of hard disk %d not found.", i)
+			}
+			ref = *backing.GetVirtualDeviceFileBackingInfo().Datastore
+		}
+		spec.Disk = append(spec.Disk, types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId:    disks[i].GetVirtualDevice().Key,
+			Datastore: ref,
+		})
+		targets = append(targets, ref)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	// Finders accept absolute paths, which may refer to another datacenter.
+	for _, ref := range targets {
+		targetDC, err := getEntityDatacenter(c, ref)
+		if err != nil {
+			return err
+		}
+		if targetDC.Reference() != dc.Reference() {
+			return fmt.Errorf("%s %s is in another datacenter: virtual machines can't be migrated to another datacenter or vCenter.", ref.Type, ref.Value)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	log.Printf("[INFO] Migrating virtual machine %s: %#v", d.Id(), spec)
+	task, err := vm.Relocate(ctx, spec, types.VirtualMachineMovePriorityDefaultPriority)
+	if err != nil {
+		return err
+	}
+	if err := waitForTaskWithProgress(ctx, task, "Migrating virtual machine "+d.Id()); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("Timed out migrating virtual machine %s: the migration may still be running in vCenter.", d.Id())
+		}
+		return err
+	}
+	return nil
+}
+
+// findMigrationDatastore finds the datastore to migrate a virtual machine or
+// a disk to. Datastore clusters are rejected, because Storage DRS doesn't
+// place disks migrated by Terraform.
+func findMigrationDatastore(c *govmomi.Client, finder *find.Finder, f *object.DatacenterFolders, name string) (*object.Datastore, error) {
+	datastore, storagePod, err := findDatastoreOrStoragePod(c, finder, f, name)
+	if err != nil {
+		return nil, err
+	}
+	if storagePod != nil {
+		return nil, fmt.Errorf("Migration into datastore cluster %s is not supported: specify a datastore of the cluster.", name)
+	}
+	return datastore, nil
+}
+
+// hasDiskDatastoreChange returns true if the datastore of any disk is changed.
+func hasDiskDatastoreChange(d *schema.ResourceData) bool {
+	for i := 0; i < d.Get("disk.#").(int); i++ {
+		if d.HasChange(fmt.Sprintf("disk.%d.datastore", i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForTaskWithProgress waits for a task to be completed, logging its progress.
+func waitForTaskWithProgress(ctx context.Context, task *object.Task, name string) error {
+	reports := make(chan progress.Report)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logged := float32(-1)
+		for r := range reports {
+			// Log every 10 percent not to flood the log.
+			if p := r.Percentage(); p-logged >= 10 || (p == 100 && logged != 100) {
+				log.Printf("[INFO] %s: %.0f%%", name, p)
+				logged = p
+			}
+		}
+	}()
+
+	_, err := task.WaitForResult(ctx, progressSinker(reports))
+	<-done
+	return err
+}
+
+// progressSinker is a progress.Sinker which sends reports to a channel.
+type progressSinker chan progress.Report
+
+func (s progressSinker) Sink() chan<- progress.Report {
+	return s
+}
+
+// waitForTask waits for a task to be completed.
+func waitForTask(task *object.Task, err error) error {
+	if err != nil {
+		return err
+	}
+	return task.Wait(context.TODO())
+}
+
+// getHardDiskIO gets the I/O settings of a disk block.
+func getHardDiskIO(d *schema.ResourceData, prefix string) hardDisk {
+	return hardDisk{
+		iops:          int64(d.Get(prefix + ".iops").(int)),
+		ioReservation: d.Get(prefix + ".io_reservation").(int),
+		ioShareLevel:  d.Get(prefix + ".io_share_level").(string),
+		ioShareCount:  d.Get(prefix + ".io_share_count").(int),
+	}
+}
+
+// createStorageIOAllocation creates StorageIOAllocationInfo for the Hard Disk.
+func (hd *hardDisk) createStorageIOAllocation() (*types.StorageIOAllocationInfo, error) {
+	shares, err := createSharesInfo("io", hd.ioShareLevel, hd.ioShareCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// -1 means unlimited IOPS.
+	limit := int64(-1)
+	if hd.iops > 0 {
+		limit = hd.iops
+	}
+
+	return &types.StorageIOAllocationInfo{
+		Limit:       limit,
+		Reservation: hd.ioReservation,
+		Shares:      shares,
+	}, nil
+}
+
+// setHardDiskIO changes the I/O settings of the index-th Hard Disk of the VirtualMachine.
+func setHardDiskIO(vm *object.VirtualMachine, index int, hd hardDisk) error {
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	if index >= len(disks) {
+		return fmt.Errorf("Hard disk %d not found.", index)
+	}
+
+	allocation, err := hd.createStorageIOAllocation()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] setHardDiskIO: %#v", allocation)
+
+	// EditDevice would omit an I/O reservation of 0, so it couldn't be reset.
+	spec := types.VirtualMachineConfigSpec{
+		DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Operation: types.VirtualDeviceConfigSpecOperationEdit,
+				Device:    newVirtualDisk(disks[index].(*types.VirtualDisk), allocation),
+			},
+		},
+	}
+	task, err := vm.Reconfigure(context.TODO(), spec)
+	return waitForTask(task, err)
+}
+
+// addHardDisks adds Hard Disks to a new VirtualMachine. A disk without a
+// datastore is created with the VirtualMachine files, or placed by Storage DRS
+// if the VirtualMachine was placed in the datastore cluster rootPod.
+func addHardDisks(c *govmomi.Client, vm *object.VirtualMachine, disks []hardDisk, rootPod *object.StoragePod, diskType string) error {
+	log.Printf("[DEBUG] add hard disk: %v", disks)
+	if len(disks) == 0 {
+		return nil
 	}
 
-	task, err = vm.Destroy(context.TODO())
+	dc, err := getEntityDatacenter(c, vm.Reference())
 	if err != nil {
 		return err
 	}
-
-	err = task.Wait(context.TODO())
+	finder := find.NewFinder(c.Client, true)
+	finder = finder.SetDatacenter(dc)
+	dcFolders, err := dc.Folders(context.TODO())
 	if err != nil {
 		return err
 	}
 
-	d.SetId("")
-	return nil
-}
-
-func waitForNetworkingActive(client *govmomi.Client, datacenter, name string) resource.StateRefreshFunc {
-	return func() (interface{}, string, error) {
-		dc, err := getDatacenter(client, datacenter)
-		if err != nil {
-			log.Printf("[ERROR] %#v", err)
-			return nil, "", err
-		}
-		finder := find.NewFinder(client.Client, true)
-		finder = finder.SetDatacenter(dc)
-
-		vm, err := finder.VirtualMachine(context.TODO(), name)
-		if err != nil {
-			log.Printf("[ERROR] %#v", err)
-			return nil, "", err
-		}
-
-		var mvm mo.VirtualMachine
-		collector := property.DefaultCollector(client.Client)
-		if err := collector.RetrieveOne(context.TODO(), vm.Reference(), []string{"summary"}, &mvm); err != nil {
-			log.Printf("[ERROR] %#v", err)
-			return nil, "", err
+	for _, hd := range disks {
+		datastore, storagePod := (*object.Datastore)(nil), rootPod
+		if hd.datastore != "" {
+			datastore, storagePod, err = findDatastoreOrStoragePod(c, finder, dcFolders, hd.datastore)
+			if err != nil {
+				return err
+			}
 		}
-
-		if mvm.Summary.Guest.IpAddress != "" {
-			log.Printf("[DEBUG] IP address with DHCP: %v", mvm.Summary.Guest.IpAddress)
-			return mvm.Summary, "active", err
-		} else {
-			log.Printf("[DEBUG] Waiting for IP address")
-			return nil, "pending", err
+		if err := addHardDisk(c, vm, hd, diskType, datastore, storagePod); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-// getDatacenter gets datacenter object
-func getDatacenter(c *govmomi.Client, dc string) (*object.Datacenter, error) {
-	finder := find.NewFinder(c.Client, true)
-	if dc != "" {
-		d, err := finder.Datacenter(context.TODO(), dc)
-		return d, err
-	} else {
-		d, err := finder.DefaultDatacenter(context.TODO())
-		return d, err
-	}
-}
-
-// addHardDisk adds a new Hard Disk to the VirtualMachine.
-func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string) error {
+// addHardDisk adds a new Hard Disk to the VirtualMachine. The disk is created
+// in datastore or, if storagePod is given, where Storage DRS recommends. It's
+// created with the VirtualMachine files if both are nil.
+func addHardDisk(c *govmomi.Client, vm *object.VirtualMachine, hd hardDisk, diskType string, datastore *object.Datastore, storagePod *object.StoragePod) error {
 	devices, err := vm.Device(context.TODO())
 	if err != nil {
 		return err
@@ -531,17 +1800,27 @@ func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string) e
 	log.Printf("[DEBUG] disk controller: %#v\n", controller)
 
 	disk := devices.CreateDisk(controller, "")
+	backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if datastore != nil {
+		var mds mo.Datastore
+		if err := datastore.Properties(context.TODO(), datastore.Reference(), []string{"name"}, &mds); err != nil {
+			return err
+		}
+		// vSphere chooses the file name when only the datastore is given.
+		dsr := datastore.Reference()
+		backing.FileName = fmt.Sprintf("[%s]", mds.Name)
+		backing.Datastore = &dsr
+	}
 	existing := devices.SelectByBackingInfo(disk.Backing)
 	log.Printf("[DEBUG] disk: %#v\n", disk)
 
 	if len(existing) == 0 {
-		disk.CapacityInKB = int64(size * 1024 * 1024)
-		if iops != 0 {
-			disk.StorageIOAllocation = &types.StorageIOAllocationInfo{
-				Limit: iops,
-			}
+		disk.CapacityInKB = int64(hd.size * 1024 * 1024)
+		allocation, err := hd.createStorageIOAllocation()
+		if err != nil {
+			return err
 		}
-		backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		disk.StorageIOAllocation = allocation
 
 		if diskType == "eager_zeroed" {
 			// eager zeroed thick virtual disk
@@ -555,6 +1834,20 @@ func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string) e
 		log.Printf("[DEBUG] addHardDisk: %#v\n", disk)
 		log.Printf("[DEBUG] addHardDisk: %#v\n", disk.CapacityInKB)
 
+		if storagePod != nil {
+			disk.Key = -1
+			configSpec := types.VirtualMachineConfigSpec{
+				DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+					&types.VirtualDeviceConfigSpec{
+						Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+						FileOperation: types.VirtualDeviceConfigSpecFileOperationCreate,
+						Device:        disk,
+					},
+				},
+			}
+			sps := createStoragePlacementSpecReconfigure(vm, *storagePod, configSpec, disk.Key)
+			return applyStorageDrsRecommendation(c, sps)
+		}
 		return vm.AddDevice(context.TODO(), disk)
 	} else {
 		log.Printf("[DEBUG] addHardDisk: Disk already present.\n")
@@ -563,9 +1856,106 @@ func addHardDisk(vm *object.VirtualMachine, size, iops int64, diskType string) e
 	}
 }
 
+// findNetwork finds a network by its label, which is a managed object ID such
+// as "dvportgroup-12", an inventory path such as "/Datacenter/network/prod/web"
+// or "prod/web", or an exact name. A name must match only one network of the
+// datacenter.
+func findNetwork(c *govmomi.Client, f *find.Finder, label string) (object.NetworkReference, error) {
+	if ref, ok := networkReference(label); ok {
+		if _, err := getNetworkName(c, ref); err != nil {
+			return nil, err
+		}
+		return object.NewReference(c.Client, ref).(object.NetworkReference), nil
+	}
+	if strings.Contains(label, "/") {
+		return f.Network(context.TODO(), label)
+	}
+
+	paths, err := findNetworkPaths(f, label)
+	if err != nil {
+		return nil, err
+	}
+	switch len(paths) {
+	case 0:
+		return nil, fmt.Errorf("Network %s not found.", label)
+	case 1:
+		return f.Network(context.TODO(), paths[0])
+	default:
+		return nil, fmt.Errorf("Network %s is ambiguous, use one of the paths: %s", label, strings.Join(paths, ", "))
+	}
+}
+
+// networkReference returns the reference of a network if label is its managed
+// object ID, such as "network-11" or "dvportgroup-12".
+func networkReference(label string) (types.ManagedObjectReference, bool) {
+	for prefix, t := range map[string]string{"network-": "Network", "dvportgroup-": "DistributedVirtualPortgroup"} {
+		if !strings.HasPrefix(label, prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(label, prefix)); err != nil {
+			return types.ManagedObjectReference{}, false
+		}
+		return types.ManagedObjectReference{Type: t, Value: label}, true
+	}
+	return types.ManagedObjectReference{}, false
+}
+
+// findNetworkPaths finds the inventory paths of the networks named name in the
+// network folder of the datacenter and its subfolders. Opaque networks are left
+// out because the finder can't get them by path.
+func findNetworkPaths(f *find.Finder, name string) ([]string, error) {
+	var paths []string
+	folders := []string{"network"}
+	for len(folders) > 0 {
+		es, err := f.ManagedObjectListChildren(context.TODO(), folders[0])
+		if err != nil {
+			return nil, err
+		}
+		folders = folders[1:]
+
+		for _, e := range es {
+			switch e.Object.Reference().Type {
+			case "Folder":
+				folders = append(folders, e.Path)
+			case "Network", "DistributedVirtualPortgroup":
+				if path.Base(e.Path) == name {
+					paths = append(paths, e.Path)
+				}
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// getNetworkName gets the name of a network.
+func getNetworkName(c *govmomi.Client, ref types.ManagedObjectReference) (string, error) {
+	var mn mo.Network
+	if err := object.NewCommon(c.Client, ref).Properties(context.TODO(), ref, []string{"name"}, &mn); err != nil {
+		return "", err
+	}
+	return mn.Name, nil
+}
+
+// networkLabel returns the current label of a network interface if it refers
+// to the network named name, otherwise name.
+func networkLabel(c *govmomi.Client, f *find.Finder, current, name string) string {
+	if current == "" || current == name {
+		return name
+	}
+	network, err := findNetwork(c, f, current)
+	if err != nil {
+		return name
+	}
+	if n, err := getNetworkName(c, network.Reference()); err == nil && n == name {
+		return current
+	}
+	return name
+}
+
 // createNetworkDevice creates VirtualDeviceConfigSpec for Network Device.
-func createNetworkDevice(f *find.Finder, label, adapterType string) (*types.VirtualDeviceConfigSpec, error) {
-	network, err := f.Network(context.TODO(), "*"+label)
+func createNetworkDevice(c *govmomi.Client, f *find.Finder, label, adapterType string) (*types.VirtualDeviceConfigSpec, error) {
+	network, err := findNetwork(c, f, label)
 	if err != nil {
 		return nil, err
 	}
@@ -608,19 +1998,39 @@ func createNetworkDevice(f *find.Finder, label, adapterType string) (*types.Virt
 	}
 }
 
+// createIPv6AddressSpec creates CustomizationIPSettingsIpV6AddressSpec for additional IP addresses.
+// Guest customization only supports a single IPv4 address per adapter, so
+// additional addresses have to be IPv6.
+func createIPv6AddressSpec(addresses []string) (*types.CustomizationIPSettingsIpV6AddressSpec, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	spec := &types.CustomizationIPSettingsIpV6AddressSpec{}
+	for _, address := range addresses {
+		ip, ipNet, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, err
+		}
+		if ip.To4() != nil {
+			return nil, fmt.Errorf("Guest customization supports only one IPv4 address per network interface, so %s can't be set: set additional IPv4 addresses in the network config of cloud_init meta_data instead.", address)
+		}
+		prefixLength, _ := ipNet.Mask.Size()
+		spec.Ip = append(spec.Ip, &types.CustomizationFixedIpV6{
+			IpAddress:  ip.String(),
+			SubnetMask: prefixLength,
+		})
+	}
+	log.Printf("[DEBUG] IPv6 address spec: %#v", spec)
+	return spec, nil
+}
+
 // createVMRelocateSpec creates VirtualMachineRelocateSpec to set a place for a new VirtualMachine.
 func createVMRelocateSpec(rp *object.ResourcePool, ds *object.Datastore, vm *object.VirtualMachine) (types.VirtualMachineRelocateSpec, error) {
-	var key int
-
-	devices, err := vm.Device(context.TODO())
+	key, err := templateDiskKey(vm)
 	if err != nil {
 		return types.VirtualMachineRelocateSpec{}, err
 	}
-	for _, d := range devices {
-		if devices.Type(d) == "disk" {
-			key = d.GetVirtualDevice().Key
-		}
-	}
 
 	rpr := rp.Reference()
 	dsr := ds.Reference()
@@ -629,18 +2039,40 @@ func createVMRelocateSpec(rp *object.ResourcePool, ds *object.Datastore, vm *obj
 		Pool:      &rpr,
 		Disk: []types.VirtualMachineRelocateSpecDiskLocator{
 			types.VirtualMachineRelocateSpecDiskLocator{
-				Datastore: dsr,
-				DiskBackingInfo: &types.VirtualDiskFlatVer2BackingInfo{
-					DiskMode:        "persistent",
-					ThinProvisioned: types.NewBool(false),
-					EagerlyScrub:    types.NewBool(true),
-				},
-				DiskId: key,
+				Datastore:       dsr,
+				DiskBackingInfo: createCloneDiskBacking(),
+				DiskId:          key,
 			},
 		},
 	}, nil
 }
 
+// templateDiskKey returns the device key of the disk of a VM template.
+func templateDiskKey(vm *object.VirtualMachine) (int, error) {
+	var key int
+
+	devices, err := vm.Device(context.TODO())
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range devices {
+		if devices.Type(d) == "disk" {
+			key = d.GetVirtualDevice().Key
+		}
+	}
+	return key, nil
+}
+
+// createCloneDiskBacking creates the backing of the disk cloned from a VM
+// template, which is persistent and eagerly zeroed.
+func createCloneDiskBacking() *types.VirtualDiskFlatVer2BackingInfo {
+	return &types.VirtualDiskFlatVer2BackingInfo{
+		DiskMode:        "persistent",
+		ThinProvisioned: types.NewBool(false),
+		EagerlyScrub:    types.NewBool(true),
+	}
+}
+
 // getDatastoreObject gets datastore object.
 func getDatastoreObject(client *govmomi.Client, f *object.DatacenterFolders, name string) (types.ManagedObjectReference, error) {
 	s := object.NewSearchIndex(client.Client)
@@ -648,98 +2080,485 @@ func getDatastoreObject(client *govmomi.Client, f *object.DatacenterFolders, nam
 	if err != nil {
 		return types.ManagedObjectReference{}, err
 	}
+	if ref == nil {
+		return types.ManagedObjectReference{}, fmt.Errorf("Datastore or datastore cluster %s not found.", name)
+	}
 	log.Printf("[DEBUG] getDatastoreObject: reference: %#v", ref)
 	return ref.Reference(), nil
 }
 
+// findDatastoreOrStoragePod finds a Datastore, or a StoragePod if name is a
+// datastore cluster. The default datastore is returned if name is empty.
+func findDatastoreOrStoragePod(c *govmomi.Client, finder *find.Finder, f *object.DatacenterFolders, name string) (*object.Datastore, *object.StoragePod, error) {
+	if name == "" {
+		datastore, err := finder.DefaultDatastore(context.TODO())
+		return datastore, nil, err
+	}
+	if datastore, err := finder.Datastore(context.TODO(), name); err == nil {
+		return datastore, nil, nil
+	}
+
+	// TODO: datastore cluster support in govmomi finder function
+	ref, err := getDatastoreObject(c, f, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ref.Type == "StoragePod" {
+		return nil, &object.StoragePod{Folder: object.NewFolder(c.Client, ref)}, nil
+	}
+	return object.NewDatastore(c.Client, ref), nil, nil
+}
+
 // createStoragePlacementSpecCreate creates StoragePlacementSpec for create action.
-func createStoragePlacementSpecCreate(f *object.DatacenterFolders, rp *object.ResourcePool, storagePod object.StoragePod, configSpec types.VirtualMachineConfigSpec) types.StoragePlacementSpec {
-	vmfr := f.VmFolder.Reference()
+func createStoragePlacementSpecCreate(folder *object.Folder, rp *object.ResourcePool, host *object.HostSystem, storagePod object.StoragePod, configSpec types.VirtualMachineConfigSpec) types.StoragePlacementSpec {
+	fr := folder.Reference()
 	rpr := rp.Reference()
 	spr := storagePod.Reference()
 
 	sps := types.StoragePlacementSpec{
-		Type:       "create",
+		Type:       string(types.StoragePlacementSpecPlacementTypeCreate),
 		ConfigSpec: &configSpec,
 		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
 			StoragePod: &spr,
 		},
-		Folder:       &vmfr,
+		Folder:       &fr,
 		ResourcePool: &rpr,
 	}
-	log.Printf("[DEBUG] findDatastore: StoragePlacementSpec: %#v\n", sps)
-	return sps
+	if host != nil {
+		hr := host.Reference()
+		sps.Host = &hr
+	}
+	return sps
+}
+
+// createStoragePlacementSpecClone creates StoragePlacementSpec for clone action.
+// Storage DRS chooses the datastore of the disk, so its backing is set to the
+// pod disk locator instead of the relocate spec.
+func createStoragePlacementSpecClone(vm *object.VirtualMachine, folder *object.Folder, storagePod object.StoragePod, name string, cloneSpec types.VirtualMachineCloneSpec, diskKey int) types.StoragePlacementSpec {
+	vmr := vm.Reference()
+	fr := folder.Reference()
+	spr := storagePod.Reference()
+
+	return types.StoragePlacementSpec{
+		Type: string(types.StoragePlacementSpecPlacementTypeClone),
+		Vm:   &vmr,
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			StoragePod: &spr,
+			InitialVmConfig: []types.VmPodConfigForPlacement{
+				types.VmPodConfigForPlacement{
+					StoragePod: spr,
+					Disk: []types.PodDiskLocator{
+						types.PodDiskLocator{
+							DiskId:          diskKey,
+							DiskBackingInfo: createCloneDiskBacking(),
+						},
+					},
+				},
+			},
+		},
+		CloneSpec: &cloneSpec,
+		CloneName: name,
+		Folder:    &fr,
+	}
+}
+
+// createStoragePlacementSpecReconfigure creates StoragePlacementSpec for
+// reconfigure action, which adds the disk with the key diskKey in configSpec.
+func createStoragePlacementSpecReconfigure(vm *object.VirtualMachine, storagePod object.StoragePod, configSpec types.VirtualMachineConfigSpec, diskKey int) types.StoragePlacementSpec {
+	vmr := vm.Reference()
+
+	return types.StoragePlacementSpec{
+		Type:       string(types.StoragePlacementSpecPlacementTypeReconfigure),
+		Vm:         &vmr,
+		ConfigSpec: &configSpec,
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			InitialVmConfig: []types.VmPodConfigForPlacement{
+				types.VmPodConfigForPlacement{
+					StoragePod: storagePod.Reference(),
+					Disk: []types.PodDiskLocator{
+						types.PodDiskLocator{DiskId: diskKey},
+					},
+				},
+			},
+		},
+	}
+}
+
+// applyStorageDrsRecommendation asks Storage DRS to place the disks of a
+// StoragePlacementSpec and applies its first recommendation, which carries out
+// the create, clone or reconfigure action of the spec.
+func applyStorageDrsRecommendation(c *govmomi.Client, sps types.StoragePlacementSpec) error {
+	log.Printf("[DEBUG] applyStorageDrsRecommendation: StoragePlacementSpec: %#v\n", sps)
+
+	srm := object.NewStorageResourceManager(c.Client)
+	rds, err := srm.RecommendDatastores(context.TODO(), sps)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DEBUG] applyStorageDrsRecommendation: recommendDatastores: %#v\n", rds)
+
+	if len(rds.Recommendations) == 0 {
+		return fmt.Errorf("Storage DRS has no recommendation for %s placement%s", sps.Type, storageDrsFaultMessage(rds.DrsFault))
+	}
+
+	task, err := srm.ApplyStorageDrsRecommendation(context.TODO(), []string{rds.Recommendations[0].Key})
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(context.TODO(), nil)
+	return err
+}
+
+// storageDrsFaultMessage formats the reasons why Storage DRS made no
+// recommendation to be appended to an error message.
+func storageDrsFaultMessage(fault *types.ClusterDrsFaults) string {
+	if fault == nil {
+		return "."
+	}
+	var messages []string
+	for _, v := range fault.FaultsByVm {
+		for _, f := range v.GetClusterDrsFaultsFaultsByVm().Fault {
+			messages = append(messages, f.LocalizedMessage)
+		}
+	}
+	if len(messages) == 0 {
+		return fmt.Sprintf(": %s.", fault.Reason)
+	}
+	return fmt.Sprintf(": %s: %s", fault.Reason, strings.Join(messages, "; "))
+}
+
+// createConfigSpec creates VirtualMachineConfigSpec with the settings shared by create and deploy actions.
+func (vm *virtualMachine) createConfigSpec() types.VirtualMachineConfigSpec {
+	configSpec := types.VirtualMachineConfigSpec{
+		NumCPUs:             vm.vcpu,
+		NumCoresPerSocket:   vm.numCoresPerSocket,
+		MemoryMB:            vm.memoryMb,
+		CpuHotAddEnabled:    types.NewBool(vm.cpuHotAddEnabled),
+		CpuHotRemoveEnabled: types.NewBool(vm.cpuHotRemoveEnabled),
+		MemoryHotAddEnabled: types.NewBool(vm.memoryHotAddEnabled),
+	}
+	if vm.cpuAllocation != nil {
+		configSpec.CpuAllocation = vm.cpuAllocation
+	}
+	if vm.memoryAllocation != nil {
+		configSpec.MemoryAllocation = vm.memoryAllocation
+	}
+	if vm.memoryLockedToMax {
+		configSpec.MemoryReservationLockedToMax = types.NewBool(true)
+	}
+	if vm.numaVcpuMaxPerNode > 0 {
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, numaOptionValue(vm.numaVcpuMaxPerNode))
+	}
+	for _, k := range sortedKeys(vm.extraConfig) {
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, &types.OptionValue{
+			Key:   k,
+			Value: vm.extraConfig[k],
+		})
+	}
+	for _, k := range sortedKeys(vm.guestInfo) {
+		configSpec.ExtraConfig = append(configSpec.ExtraConfig, &types.OptionValue{
+			Key:   k,
+			Value: vm.guestInfo[k],
+		})
+	}
+	return configSpec
+}
+
+// createExtraConfigChange creates OptionValues to change extra config from old to new.
+// Keys removed from the map are cleared with an empty value.
+func createExtraConfigChange(old, new map[string]interface{}) []types.BaseOptionValue {
+	values := make(map[string]string)
+	for k := range old {
+		values[k] = ""
+	}
+	for k, v := range new {
+		values[k] = v.(string)
+	}
+
+	var options []types.BaseOptionValue
+	for _, k := range sortedKeys(values) {
+		options = append(options, &types.OptionValue{
+			Key:   k,
+			Value: values[k],
+		})
+	}
+	log.Printf("[DEBUG] extra config change: %#v", values)
+	return options
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createResourceAllocation creates ResourceAllocationInfo from the <prefix>_reservation,
+// <prefix>_limit, <prefix>_share_level and <prefix>_share_count arguments.
+func createResourceAllocation(d *schema.ResourceData, prefix string) (*ResourceAllocationInfo, error) {
+	shares, err := createSharesInfo(prefix, d.Get(prefix+"_share_level").(string), d.Get(prefix+"_share_count").(int))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResourceAllocationInfo{
+		Reservation: int64(d.Get(prefix + "_reservation").(int)),
+		Limit:       int64(d.Get(prefix + "_limit").(int)),
+		Shares:      shares,
+	}, nil
+}
+
+// getVAppProperties gets the properties of a vapp block.
+func getVAppProperties(vapp []interface{}) map[string]string {
+	properties := make(map[string]string)
+	if len(vapp) == 0 || vapp[0] == nil {
+		return properties
+	}
+	if v, ok := vapp[0].(map[string]interface{})["properties"]; ok {
+		for k, v := range v.(map[string]interface{}) {
+			properties[k] = v.(string)
+		}
+	}
+	return properties
+}
+
+// createVAppConfigSpec creates VmConfigSpec to set vApp properties of the VirtualMachine.
+// The properties are matched by key against the OVF properties of the VirtualMachine.
+// The properties in removed are reset to their default values.
+func createVAppConfigSpec(c *govmomi.Client, vm *object.VirtualMachine, properties map[string]string, removed []string) (*types.VmConfigSpec, error) {
+	var mvm mo.VirtualMachine
+	collector := property.DefaultCollector(c.Client)
+	if err := collector.RetrieveOne(context.TODO(), vm.Reference(), []string{"config"}, &mvm); err != nil {
+		return nil, err
+	}
+	if mvm.Config == nil || mvm.Config.VAppConfig == nil {
+		return nil, fmt.Errorf("Virtual machine %s has no vApp properties.", vm)
+	}
+
+	spec, err := createVAppPropertySpec(mvm.Config.VAppConfig.GetVmConfigInfo().Property, properties, removed)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] vApp config spec: %#v", spec)
+	return spec, nil
+}
+
+// createVAppPropertySpec creates VmConfigSpec which sets the properties and
+// resets the removed properties to their default values. The properties are
+// matched by key against the defined OVF properties.
+func createVAppPropertySpec(definedProperties []types.VAppPropertyInfo, properties map[string]string, removed []string) (*types.VmConfigSpec, error) {
+	defined := make(map[string]types.VAppPropertyInfo)
+	for _, p := range definedProperties {
+		defined[p.Id] = p
+	}
+
+	valid := make([]string, 0, len(defined))
+	for k := range defined {
+		valid = append(valid, k)
+	}
+	if err := validateVAppPropertyKeys(properties, valid); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for _, k := range removed {
+		if p, ok := defined[k]; ok {
+			values[k] = p.DefaultValue
+		}
+	}
+	for k, v := range properties {
+		if p := defined[k]; p.UserConfigurable != nil && !*p.UserConfigurable {
+			return nil, fmt.Errorf("vApp property %s is not user configurable.", k)
+		}
+		values[k] = v
+	}
+
+	spec := &types.VmConfigSpec{}
+	for _, k := range sortedKeys(values) {
+		info := defined[k]
+		spec.Property = append(spec.Property, types.VAppPropertySpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{
+				Operation: types.ArrayUpdateOperationEdit,
+			},
+			Info: &types.VAppPropertyInfo{
+				Key:   info.Key,
+				Id:    info.Id,
+				Value: values[k],
+			},
+		})
+	}
+	return spec, nil
+}
+
+// validateVAppPropertyKeys checks that all keys of properties are in valid.
+func validateVAppPropertyKeys(properties map[string]string, valid []string) error {
+	defined := make(map[string]bool)
+	for _, k := range valid {
+		defined[k] = true
+	}
+
+	var unknown []string
+	for k := range properties {
+		if !defined[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		sort.Strings(valid)
+		return fmt.Errorf("Unknown vApp properties: %s. Valid properties are: %s", strings.Join(unknown, ", "), strings.Join(valid, ", "))
+	}
+	return nil
+}
+
+// createSharesInfo creates SharesInfo from the <prefix>_share_level and <prefix>_share_count arguments.
+func createSharesInfo(prefix, level string, count int) (*types.SharesInfo, error) {
+	shares := &types.SharesInfo{
+		Level: types.SharesLevel(level),
+	}
+	switch shares.Level {
+	case types.SharesLevelLow, types.SharesLevelNormal, types.SharesLevelHigh:
+	case types.SharesLevelCustom:
+		if count <= 0 {
+			return nil, fmt.Errorf("%s_share_count is required when %s_share_level is custom.", prefix, prefix)
+		}
+		shares.Shares = count
+	default:
+		return nil, fmt.Errorf("Invalid %s_share_level %q: it must be one of low, normal, high or custom.", prefix, level)
+	}
+	return shares, nil
+}
+
+// setResourceAllocation sets the <prefix>_reservation, <prefix>_limit, <prefix>_share_level
+// and <prefix>_share_count arguments from ResourceAllocationInfo.
+func setResourceAllocation(d *schema.ResourceData, prefix string, allocation *types.ResourceAllocationInfo) {
+	d.Set(prefix+"_reservation", allocation.Reservation)
+	d.Set(prefix+"_limit", allocation.Limit)
+	if allocation.Shares != nil {
+		d.Set(prefix+"_share_level", string(allocation.Shares.Level))
+		d.Set(prefix+"_share_count", allocation.Shares.Shares)
+	}
+}
+
+// numaOptionValue creates OptionValue for the maximum number of vCPUs per virtual NUMA node.
+// An empty value removes the setting.
+func numaOptionValue(n int) *types.OptionValue {
+	value := ""
+	if n > 0 {
+		value = strconv.Itoa(n)
+	}
+	return &types.OptionValue{
+		Key:   numaVcpuMaxPerVirtualNodeKey,
+		Value: value,
+	}
 }
 
-// createStoragePlacementSpecClone creates StoragePlacementSpec for clone action.
-func createStoragePlacementSpecClone(c *govmomi.Client, f *object.DatacenterFolders, vm *object.VirtualMachine, rp *object.ResourcePool, storagePod object.StoragePod) types.StoragePlacementSpec {
-	vmr := vm.Reference()
-	vmfr := f.VmFolder.Reference()
-	rpr := rp.Reference()
-	spr := storagePod.Reference()
+// findResourcePool finds the resource pool for the VirtualMachine.
+func (vm *virtualMachine) findResourcePool(finder *find.Finder) (*object.ResourcePool, error) {
+	return findResourcePool(finder, vm.cluster, vm.resourcePool)
+}
 
-	var o mo.VirtualMachine
-	err := vm.Properties(context.TODO(), vmr, []string{"datastore"}, &o)
-	if err != nil {
-		return types.StoragePlacementSpec{}
+// findHost finds the host for the VirtualMachine. It returns nil if host is
+// not specified, so that DRS chooses the host.
+func (vm *virtualMachine) findHost(finder *find.Finder) (*object.HostSystem, error) {
+	if vm.host == "" {
+		return nil, nil
+	}
+	return finder.HostSystem(context.TODO(), vm.host)
+}
+
+// hostPath returns the path of a host relative to the host folder of the
+// datacenter. The current path is kept if it refers to the host, so that a
+// standalone host can be specified by its name.
+func hostPath(c *govmomi.Client, finder *find.Finder, ref types.ManagedObjectReference, current string) (string, error) {
+	if current != "" {
+		if host, err := finder.HostSystem(context.TODO(), current); err == nil && host.Reference() == ref {
+			return current, nil
+		}
 	}
-	ds := object.NewDatastore(c.Client, o.Datastore[0])
-	log.Printf("[DEBUG] findDatastore: datastore: %#v\n", ds)
 
-	devices, err := vm.Device(context.TODO())
+	p, err := inventoryPath(c, ref)
 	if err != nil {
-		return types.StoragePlacementSpec{}
+		return "", err
 	}
-
-	var key int
-	for _, d := range devices.SelectByType((*types.VirtualDisk)(nil)) {
-		key = d.GetVirtualDevice().Key
-		log.Printf("[DEBUG] findDatastore: virtual devices: %#v\n", d.GetVirtualDevice())
+	i := strings.Index(p, "/host/")
+	if i < 0 {
+		return "", fmt.Errorf("Unexpected path of host %s: %s", ref.Value, p)
 	}
+	return p[i+len("/host/"):], nil
+}
 
-	sps := types.StoragePlacementSpec{
-		Type: "clone",
-		Vm:   &vmr,
-		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
-			StoragePod: &spr,
-		},
-		CloneSpec: &types.VirtualMachineCloneSpec{
-			Location: types.VirtualMachineRelocateSpec{
-				Disk: []types.VirtualMachineRelocateSpecDiskLocator{
-					types.VirtualMachineRelocateSpecDiskLocator{
-						Datastore:       ds.Reference(),
-						DiskBackingInfo: &types.VirtualDiskFlatVer2BackingInfo{},
-						DiskId:          key,
-					},
-				},
-				Pool: &rpr,
-			},
-			PowerOn:  false,
-			Template: false,
-		},
-		CloneName: "dummy",
-		Folder:    &vmfr,
+// findResourcePool finds a resource pool by its path. If the path is empty,
+// it finds the root resource pool of the cluster, or the default resource pool.
+func findResourcePool(finder *find.Finder, cluster, resourcePool string) (*object.ResourcePool, error) {
+	if resourcePool != "" {
+		return finder.ResourcePool(context.TODO(), resourcePool)
 	}
-	return sps
+	if cluster != "" {
+		return finder.ResourcePool(context.TODO(), "*"+cluster+"/Resources")
+	}
+	return finder.DefaultResourcePool(context.TODO())
 }
 
-// findDatastore finds Datastore object.
-func findDatastore(c *govmomi.Client, sps types.StoragePlacementSpec) (*object.Datastore, error) {
-	var datastore *object.Datastore
-	log.Printf("[DEBUG] findDatastore: StoragePlacementSpec: %#v\n", sps)
+// createCustomizationSpec creates CustomizationSpec for the VirtualMachine.
+func (vm *virtualMachine) createCustomizationSpec() (*types.CustomizationSpec, error) {
+	networkConfigs := []types.CustomizationAdapterMapping{}
+	for _, network := range vm.networkInterfaces {
+		ipV6Spec, err := createIPv6AddressSpec(network.additionalIPAddresses)
+		if err != nil {
+			return nil, err
+		}
 
-	srm := object.NewStorageResourceManager(c.Client)
-	rds, err := srm.RecommendDatastores(context.TODO(), sps)
-	if err != nil {
-		return nil, err
-	}
-	log.Printf("[DEBUG] findDatastore: recommendDatastores: %#v\n", rds)
+		var ipSetting types.CustomizationIPSettings
+		if network.ipAddress == "" {
+			ipSetting = types.CustomizationIPSettings{
+				Ip: &types.CustomizationDhcpIpGenerator{},
+			}
+		} else {
+			log.Printf("[DEBUG] gateway: %v", vm.gateway)
+			log.Printf("[DEBUG] ip address: %v", network.ipAddress)
+			log.Printf("[DEBUG] subnet mask: %v", network.subnetMask)
+			ipSetting = types.CustomizationIPSettings{
+				Gateway: []string{
+					vm.gateway,
+				},
+				Ip: &types.CustomizationFixedIp{
+					IpAddress: network.ipAddress,
+				},
+				SubnetMask: network.subnetMask,
+			}
+		}
+		ipSetting.IpV6Spec = ipV6Spec
+		ipSetting.DnsServerList = network.dnsServers
 
-	spa := rds.Recommendations[0].Action[0].(*types.StoragePlacementAction)
-	datastore = object.NewDatastore(c.Client, spa.Destination)
-	log.Printf("[DEBUG] findDatastore: datastore: %#v", datastore)
+		// network config
+		config := types.CustomizationAdapterMapping{
+			Adapter: ipSetting,
+		}
+		networkConfigs = append(networkConfigs, config)
+	}
+	log.Printf("[DEBUG] network configs: %v", networkConfigs)
 
-	return datastore, nil
+	customSpec := &types.CustomizationSpec{
+		Identity: &types.CustomizationLinuxPrep{
+			HostName: &types.CustomizationFixedName{
+				Name: strings.Split(vm.name, ".")[0],
+			},
+			Domain:     vm.domain,
+			TimeZone:   vm.timeZone,
+			HwClockUTC: types.NewBool(true),
+		},
+		GlobalIPSettings: types.CustomizationGlobalIPSettings{
+			DnsSuffixList: vm.dnsSuffixes,
+			DnsServerList: vm.dnsServers,
+		},
+		NicSettingMap: networkConfigs,
+	}
+	log.Printf("[DEBUG] custom spec: %v", customSpec)
+	return customSpec, nil
 }
 
 // createVirtualMchine creates a new VirtualMachine.
@@ -751,24 +2570,9 @@ func (vm *virtualMachine) createVirtualMachine(c *govmomi.Client) error {
 	finder := find.NewFinder(c.Client, true)
 	finder = finder.SetDatacenter(dc)
 
-	var resourcePool *object.ResourcePool
-	if vm.resourcePool == "" {
-		if vm.cluster == "" {
-			resourcePool, err = finder.DefaultResourcePool(context.TODO())
-			if err != nil {
-				return err
-			}
-		} else {
-			resourcePool, err = finder.ResourcePool(context.TODO(), "*"+vm.cluster+"/Resources")
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		resourcePool, err = finder.ResourcePool(context.TODO(), vm.resourcePool)
-		if err != nil {
-			return err
-		}
+	resourcePool, err := vm.findResourcePool(finder)
+	if err != nil {
+		return err
 	}
 	log.Printf("[DEBUG] resource pool: %#v", resourcePool)
 
@@ -777,11 +2581,21 @@ func (vm *virtualMachine) createVirtualMachine(c *govmomi.Client) error {
 		return err
 	}
 
+	folder, err := getVMFolder(c, dc, vm.folder)
+	if err != nil {
+		return err
+	}
+
+	host, err := vm.findHost(finder)
+	if err != nil {
+		return err
+	}
+
 	// network
 	networkDevices := []types.BaseVirtualDeviceConfigSpec{}
 	for _, network := range vm.networkInterfaces {
 		// network device
-		nd, err := createNetworkDevice(finder, network.label, "e1000")
+		nd, err := createNetworkDevice(c, finder, network.label, "e1000")
 		if err != nil {
 			return err
 		}
@@ -789,53 +2603,18 @@ func (vm *virtualMachine) createVirtualMachine(c *govmomi.Client) error {
 	}
 
 	// make config spec
-	configSpec := types.VirtualMachineConfigSpec{
-		GuestId:           "otherLinux64Guest",
-		Name:              vm.name,
-		NumCPUs:           vm.vcpu,
-		NumCoresPerSocket: 1,
-		MemoryMB:          vm.memoryMb,
-		DeviceChange:      networkDevices,
-	}
+	configSpec := vm.createConfigSpec()
+	configSpec.GuestId = "otherLinux64Guest"
+	configSpec.Name = vm.name
+	configSpec.DeviceChange = networkDevices
 	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
 
-	var datastore *object.Datastore
-	if vm.datastore == "" {
-		datastore, err = finder.DefaultDatastore(context.TODO())
-		if err != nil {
-			return err
-		}
-	} else {
-		datastore, err = finder.Datastore(context.TODO(), vm.datastore)
-		if err != nil {
-			// TODO: datastore cluster support in govmomi finder function
-			d, err := getDatastoreObject(c, dcFolders, vm.datastore)
-			if err != nil {
-				return err
-			}
-
-			if d.Type == "StoragePod" {
-				sp := object.StoragePod{
-					object.NewFolder(c.Client, d),
-				}
-				sps := createStoragePlacementSpecCreate(dcFolders, resourcePool, sp, configSpec)
-				datastore, err = findDatastore(c, sps)
-			} else {
-				datastore = object.NewDatastore(c.Client, d)
-			}
-			if err != nil {
-				return err
-			}
-		}
+	datastore, storagePod, err := findDatastoreOrStoragePod(c, finder, dcFolders, vm.datastore)
+	if err != nil {
+		return err
 	}
-
 	log.Printf("[DEBUG] datastore: %#v", datastore)
 
-	var mds mo.Datastore
-	if err = datastore.Properties(context.TODO(), datastore.Reference(), []string{"name"}, &mds); err != nil {
-		return err
-	}
-	log.Printf("[DEBUG] datastore: %#v", mds.Name)
 	scsi, err := object.SCSIControllerTypes().CreateSCSIController("scsi")
 	if err != nil {
 		log.Printf("[ERROR] %s", err)
@@ -845,29 +2624,49 @@ func (vm *virtualMachine) createVirtualMachine(c *govmomi.Client) error {
 		Operation: types.VirtualDeviceConfigSpecOperationAdd,
 		Device:    scsi,
 	})
-	configSpec.Files = &types.VirtualMachineFileInfo{VmPathName: fmt.Sprintf("[%s]", mds.Name)}
 
-	task, err := dcFolders.VmFolder.CreateVM(context.TODO(), configSpec, resourcePool, nil)
-	if err != nil {
-		log.Printf("[ERROR] %s", err)
+	if storagePod != nil {
+		// Storage DRS chooses the datastore for the VirtualMachine files.
+		configSpec.Files = &types.VirtualMachineFileInfo{}
+		sps := createStoragePlacementSpecCreate(folder, resourcePool, host, *storagePod, configSpec)
+		if err := applyStorageDrsRecommendation(c, sps); err != nil {
+			return err
+		}
+		vm.created = true
+	} else {
+		var mds mo.Datastore
+		if err = datastore.Properties(context.TODO(), datastore.Reference(), []string{"name"}, &mds); err != nil {
+			return err
+		}
+		log.Printf("[DEBUG] datastore: %#v", mds.Name)
+		configSpec.Files = &types.VirtualMachineFileInfo{VmPathName: fmt.Sprintf("[%s]", mds.Name)}
+
+		task, err := folder.CreateVM(context.TODO(), configSpec, resourcePool, host)
+		if err != nil {
+			log.Printf("[ERROR] %s", err)
+		}
+
+		err = task.Wait(context.TODO())
+		if err != nil {
+			log.Printf("[ERROR] %s", err)
+		} else {
+			vm.created = true
+		}
 	}
 
-	err = task.Wait(context.TODO())
+	newVM, err := finder.VirtualMachine(context.TODO(), path.Join(vm.folder, vm.name))
 	if err != nil {
-		log.Printf("[ERROR] %s", err)
+		return err
 	}
+	log.Printf("[DEBUG] new vm: %v", newVM)
 
-	newVM, err := finder.VirtualMachine(context.TODO(), vm.name)
+	err = addHardDisks(c, newVM, vm.hardDisks, storagePod, "thin")
 	if err != nil {
 		return err
 	}
-	log.Printf("[DEBUG] new vm: %v", newVM)
 
-	log.Printf("[DEBUG] add hard disk: %v", vm.hardDisks)
-	for _, hd := range vm.hardDisks {
-		log.Printf("[DEBUG] add hard disk: %v", hd.size)
-		log.Printf("[DEBUG] add hard disk: %v", hd.iops)
-		err = addHardDisk(newVM, hd.size, hd.iops, "thin")
+	if vm.powerState != powerStateOff {
+		err = changePowerState(newVM, powerStateOn, false)
 		if err != nil {
 			return err
 		}
@@ -890,24 +2689,9 @@ func (vm *virtualMachine) deployVirtualMachine(c *govmomi.Client) error {
 	}
 	log.Printf("[DEBUG] template: %#v", template)
 
-	var resourcePool *object.ResourcePool
-	if vm.resourcePool == "" {
-		if vm.cluster == "" {
-			resourcePool, err = finder.DefaultResourcePool(context.TODO())
-			if err != nil {
-				return err
-			}
-		} else {
-			resourcePool, err = finder.ResourcePool(context.TODO(), "*"+vm.cluster+"/Resources")
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		resourcePool, err = finder.ResourcePool(context.TODO(), vm.resourcePool)
-		if err != nil {
-			return err
-		}
+	resourcePool, err := vm.findResourcePool(finder)
+	if err != nil {
+		return err
 	}
 	log.Printf("[DEBUG] resource pool: %#v", resourcePool)
 
@@ -916,146 +2700,122 @@ func (vm *virtualMachine) deployVirtualMachine(c *govmomi.Client) error {
 		return err
 	}
 
-	var datastore *object.Datastore
-	if vm.datastore == "" {
-		datastore, err = finder.DefaultDatastore(context.TODO())
-		if err != nil {
-			return err
-		}
-	} else {
-		datastore, err = finder.Datastore(context.TODO(), vm.datastore)
-		if err != nil {
-			// TODO: datastore cluster support in govmomi finder function
-			d, err := getDatastoreObject(c, dcFolders, vm.datastore)
-			if err != nil {
-				return err
-			}
+	folder, err := getVMFolder(c, dc, vm.folder)
+	if err != nil {
+		return err
+	}
 
-			if d.Type == "StoragePod" {
-				sp := object.StoragePod{
-					object.NewFolder(c.Client, d),
-				}
-				sps := createStoragePlacementSpecClone(c, dcFolders, template, resourcePool, sp)
-				datastore, err = findDatastore(c, sps)
-			} else {
-				datastore = object.NewDatastore(c.Client, d)
-			}
-			if err != nil {
-				return err
-			}
-		}
+	datastore, storagePod, err := findDatastoreOrStoragePod(c, finder, dcFolders, vm.datastore)
+	if err != nil {
+		return err
 	}
 	log.Printf("[DEBUG] datastore: %#v", datastore)
 
-	relocateSpec, err := createVMRelocateSpec(resourcePool, datastore, template)
+	var relocateSpec types.VirtualMachineRelocateSpec
+	if storagePod != nil {
+		// Storage DRS chooses the datastores of the clone.
+		rpr := resourcePool.Reference()
+		relocateSpec.Pool = &rpr
+	} else {
+		relocateSpec, err = createVMRelocateSpec(resourcePool, datastore, template)
+		if err != nil {
+			return err
+		}
+	}
+	host, err := vm.findHost(finder)
 	if err != nil {
 		return err
 	}
+	if host != nil {
+		ref := host.Reference()
+		relocateSpec.Host = &ref
+	}
 	log.Printf("[DEBUG] relocate spec: %v", relocateSpec)
 
 	// network
 	networkDevices := []types.BaseVirtualDeviceConfigSpec{}
-	networkConfigs := []types.CustomizationAdapterMapping{}
 	for _, network := range vm.networkInterfaces {
 		// network device
-		nd, err := createNetworkDevice(finder, network.label, "vmxnet3")
+		nd, err := createNetworkDevice(c, finder, network.label, "vmxnet3")
 		if err != nil {
 			return err
 		}
 		networkDevices = append(networkDevices, nd)
-
-		var ipSetting types.CustomizationIPSettings
-		if network.ipAddress == "" {
-			ipSetting = types.CustomizationIPSettings{
-				Ip: &types.CustomizationDhcpIpGenerator{},
-			}
-		} else {
-			log.Printf("[DEBUG] gateway: %v", vm.gateway)
-			log.Printf("[DEBUG] ip address: %v", network.ipAddress)
-			log.Printf("[DEBUG] subnet mask: %v", network.subnetMask)
-			ipSetting = types.CustomizationIPSettings{
-				Gateway: []string{
-					vm.gateway,
-				},
-				Ip: &types.CustomizationFixedIp{
-					IpAddress: network.ipAddress,
-				},
-				SubnetMask: network.subnetMask,
-			}
-		}
-
-		// network config
-		config := types.CustomizationAdapterMapping{
-			Adapter: ipSetting,
-		}
-		networkConfigs = append(networkConfigs, config)
 	}
-	log.Printf("[DEBUG] network configs: %v", networkConfigs[0].Adapter)
 
 	// make config spec
-	configSpec := types.VirtualMachineConfigSpec{
-		NumCPUs:           vm.vcpu,
-		NumCoresPerSocket: 1,
-		MemoryMB:          vm.memoryMb,
-		DeviceChange:      networkDevices,
+	configSpec := vm.createConfigSpec()
+	configSpec.DeviceChange = networkDevices
+	if len(vm.vAppProperties) > 0 {
+		vAppConfig, err := createVAppConfigSpec(c, template, vm.vAppProperties, nil)
+		if err != nil {
+			return err
+		}
+		configSpec.VAppConfig = vAppConfig
 	}
 	log.Printf("[DEBUG] virtual machine config spec: %v", configSpec)
 
-	// create CustomizationSpec
-	customSpec := types.CustomizationSpec{
-		Identity: &types.CustomizationLinuxPrep{
-			HostName: &types.CustomizationFixedName{
-				Name: strings.Split(vm.name, ".")[0],
-			},
-			Domain:     vm.domain,
-			TimeZone:   vm.timeZone,
-			HwClockUTC: types.NewBool(true),
-		},
-		GlobalIPSettings: types.CustomizationGlobalIPSettings{
-			DnsSuffixList: vm.dnsSuffixes,
-			DnsServerList: vm.dnsServers,
-		},
-		NicSettingMap: networkConfigs,
+	// cloud-init and Ignition configure the guest by themselves, so guest
+	// customization is skipped.
+	var customization *types.CustomizationSpec
+	if len(vm.guestInfo) == 0 {
+		customization, err = vm.createCustomizationSpec()
+		if err != nil {
+			return err
+		}
 	}
-	log.Printf("[DEBUG] custom spec: %v", customSpec)
 
 	// make vm clone spec
 	cloneSpec := types.VirtualMachineCloneSpec{
 		Location:      relocateSpec,
 		Template:      false,
 		Config:        &configSpec,
-		Customization: &customSpec,
-		PowerOn:       true,
+		Customization: customization,
+		PowerOn:       vm.powerState != powerStateOff,
 	}
 	log.Printf("[DEBUG] clone spec: %v", cloneSpec)
 
-	task, err := template.Clone(context.TODO(), dcFolders.VmFolder, vm.name, cloneSpec)
-	if err != nil {
-		return err
-	}
+	if storagePod != nil {
+		key, err := templateDiskKey(template)
+		if err != nil {
+			return err
+		}
+		sps := createStoragePlacementSpecClone(template, folder, *storagePod, vm.name, cloneSpec, key)
+		if err := applyStorageDrsRecommendation(c, sps); err != nil {
+			return err
+		}
+	} else {
+		task, err := template.Clone(context.TODO(), folder, vm.name, cloneSpec)
+		if err != nil {
+			return err
+		}
 
-	_, err = task.WaitForResult(context.TODO(), nil)
-	if err != nil {
-		return err
+		_, err = task.WaitForResult(context.TODO(), nil)
+		if err != nil {
+			return err
+		}
 	}
+	vm.created = true
 
-	newVM, err := finder.VirtualMachine(context.TODO(), vm.name)
+	newVM, err := finder.VirtualMachine(context.TODO(), path.Join(vm.folder, vm.name))
 	if err != nil {
 		return err
 	}
 	log.Printf("[DEBUG] new vm: %v", newVM)
 
-	ip, err := newVM.WaitForIP(context.TODO())
-	if err != nil {
-		return err
-	}
-	log.Printf("[DEBUG] ip address: %v", ip)
-
-	for i := 1; i < len(vm.hardDisks); i++ {
-		err = addHardDisk(newVM, vm.hardDisks[i].size, vm.hardDisks[i].iops, "eager_zeroed")
+	if vm.powerState != powerStateOff {
+		ip, err := newVM.WaitForIP(context.TODO())
 		if err != nil {
 			return err
 		}
+		log.Printf("[DEBUG] ip address: %v", ip)
 	}
-	return nil
+
+	// The root disk is cloned from the template, so only its I/O settings are applied.
+	err = setHardDiskIO(newVM, 0, vm.hardDisks[0])
+	if err != nil {
+		return err
+	}
+
+	return addHardDisks(c, newVM, vm.hardDisks[1:], storagePod, "eager_zeroed")
 }
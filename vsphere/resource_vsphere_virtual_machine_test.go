@@ -3,13 +3,18 @@ package vsphere
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/vmware/govmomi"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	"golang.org/x/net/context"
 )
 
@@ -115,8 +120,386 @@ func TestAccVSphereVirtualMachine_dhcp(t *testing.T) {
 	})
 }
 
+func TestAccVSphereVirtualMachine_powerState(t *testing.T) {
+	var vm virtualMachine
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	template := os.Getenv("VSPHERE_TEMPLATE")
+	label := os.Getenv("VSPHERE_NETWORK_LABEL_DHCP")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_powerState,
+					datacenter,
+					cluster,
+					label,
+					datastore,
+					template,
+					"off",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.baz", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.baz", "power_state", "off"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_powerState,
+					datacenter,
+					cluster,
+					label,
+					datastore,
+					template,
+					"on",
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.baz", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.baz", "power_state", "on"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualMachine_folder(t *testing.T) {
+	var vm virtualMachine
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	template := os.Getenv("VSPHERE_TEMPLATE")
+	label := os.Getenv("VSPHERE_NETWORK_LABEL_DHCP")
+	folder := os.Getenv("VSPHERE_FOLDER")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_folder,
+					datacenter,
+					cluster,
+					folder,
+					label,
+					datastore,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.folder", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.folder", "folder", folder),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_folder,
+					datacenter,
+					cluster,
+					"",
+					label,
+					datastore,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.folder", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.folder", "folder", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualMachine_host(t *testing.T) {
+	var vm virtualMachine
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	template := os.Getenv("VSPHERE_TEMPLATE")
+	label := os.Getenv("VSPHERE_NETWORK_LABEL_DHCP")
+	host := os.Getenv("VSPHERE_HOST")
+	host2 := os.Getenv("VSPHERE_HOST2")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_host,
+					datacenter,
+					cluster,
+					host,
+					label,
+					datastore,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.host", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.host", "host", host),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_host,
+					datacenter,
+					cluster,
+					host2,
+					label,
+					datastore,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.host", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.host", "host", host2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualMachine_relocate(t *testing.T) {
+	var vm virtualMachine
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	resourcePool := os.Getenv("VSPHERE_RESOURCE_POOL")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	datastore2 := os.Getenv("VSPHERE_DATASTORE2")
+	template := os.Getenv("VSPHERE_TEMPLATE")
+	label := os.Getenv("VSPHERE_NETWORK_LABEL_DHCP")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_relocate,
+					datacenter,
+					cluster,
+					"",
+					label,
+					datastore,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.relocate", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.relocate", "disk.0.datastore", datastore),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_relocate,
+					datacenter,
+					cluster,
+					resourcePool,
+					label,
+					datastore2,
+					template,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.relocate", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.relocate", "resource_pool", resourcePool),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.relocate", "disk.0.datastore", datastore2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualMachine_datastoreCluster(t *testing.T) {
+	var vm virtualMachine
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	cluster := os.Getenv("VSPHERE_CLUSTER")
+	datastoreCluster := os.Getenv("VSPHERE_DATASTORE_CLUSTER")
+	datastore := os.Getenv("VSPHERE_DATASTORE")
+	template := os.Getenv("VSPHERE_TEMPLATE")
+	label := os.Getenv("VSPHERE_NETWORK_LABEL_DHCP")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVirtualMachineConfig_datastoreCluster,
+					datacenter,
+					cluster,
+					label,
+					datastoreCluster,
+					template,
+					datastore,
+					datastoreCluster,
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVirtualMachineExists("vsphere_virtual_machine.sdrs", &vm),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.sdrs", "disk.0.datastore", datastoreCluster),
+					resource.TestCheckResourceAttr(
+						"vsphere_virtual_machine.sdrs", "disk.1.datastore", datastore),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVSphereVirtualMachine_invalidNumCoresPerSocket(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccCheckVSphereVirtualMachineConfig_invalidNumCoresPerSocket,
+				ExpectError: regexp.MustCompile("must be a multiple of num_cores_per_socket"),
+			},
+		},
+	})
+}
+
+func TestCreateIPv6AddressSpec(t *testing.T) {
+	spec, err := createIPv6AddressSpec([]string{"2001:db8::10/64", "2001:db8:1::10/48"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(spec.Ip) != 2 {
+		t.Fatalf("bad: %#v", spec.Ip)
+	}
+	ip := spec.Ip[1].(*types.CustomizationFixedIpV6)
+	if ip.IpAddress != "2001:db8:1::10" || ip.SubnetMask != 48 {
+		t.Fatalf("bad: %#v", ip)
+	}
+
+	if _, err := createIPv6AddressSpec([]string{"192.168.0.10/24"}); err == nil {
+		t.Fatal("expected error for IPv4 address")
+	}
+
+	if _, err := createIPv6AddressSpec([]string{"2001:db8::10"}); err == nil {
+		t.Fatal("expected error for address without prefix length")
+	}
+}
+
+func TestValidateAdditionalIPAddresses(t *testing.T) {
+	nics := []interface{}{
+		map[string]interface{}{
+			"additional_ip_addresses": []interface{}{"2001:db8::10/64", ""},
+		},
+	}
+	if err := validateAdditionalIPAddresses(nics); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	nics = append(nics, map[string]interface{}{
+		"additional_ip_addresses": []interface{}{"192.168.0.11/24"},
+	})
+	err := validateAdditionalIPAddresses(nics)
+	if err == nil || !strings.Contains(err.Error(), "network_interface.1.additional_ip_addresses") {
+		t.Fatalf("expected error for IPv4 address of network_interface.1: %v", err)
+	}
+}
+
+func TestCreateExtraConfigChange(t *testing.T) {
+	old := map[string]interface{}{
+		"disk.EnableUUID":   "TRUE",
+		"guestinfo.removed": "foo",
+	}
+	new := map[string]interface{}{
+		"disk.EnableUUID": "FALSE",
+		"guestinfo.added": "bar",
+	}
+
+	expected := map[string]string{
+		"disk.EnableUUID":   "FALSE",
+		"guestinfo.added":   "bar",
+		"guestinfo.removed": "",
+	}
+
+	options := createExtraConfigChange(old, new)
+	if len(options) != len(expected) {
+		t.Fatalf("bad: %#v", options)
+	}
+	for _, v := range options {
+		option := v.GetOptionValue()
+		if expected[option.Key] != option.Value {
+			t.Fatalf("bad value for %s: %#v", option.Key, option.Value)
+		}
+	}
+}
+
+func TestCreateStoragePlacementSpecClone(t *testing.T) {
+	vm := object.NewVirtualMachine(nil, types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-1"})
+	folder := object.NewFolder(nil, types.ManagedObjectReference{Type: "Folder", Value: "group-v1"})
+	pod := object.StoragePod{Folder: object.NewFolder(nil, types.ManagedObjectReference{Type: "StoragePod", Value: "group-p1"})}
+
+	sps := createStoragePlacementSpecClone(vm, folder, pod, "clone", types.VirtualMachineCloneSpec{}, 2000)
+	configs := sps.PodSelectionSpec.InitialVmConfig
+	if len(configs) != 1 || len(configs[0].Disk) != 1 || configs[0].Disk[0].DiskId != 2000 {
+		t.Fatalf("bad: %#v", configs)
+	}
+	backing := configs[0].Disk[0].DiskBackingInfo.(*types.VirtualDiskFlatVer2BackingInfo)
+	if backing.DiskMode != "persistent" || *backing.ThinProvisioned || !*backing.EagerlyScrub {
+		t.Fatalf("bad backing: %#v", backing)
+	}
+}
+
+func TestStorageDrsFaultMessage(t *testing.T) {
+	if m := storageDrsFaultMessage(nil); m != "." {
+		t.Fatalf("bad: %s", m)
+	}
+
+	fault := &types.ClusterDrsFaults{
+		Reason: "storagePlacement",
+		FaultsByVm: []types.BaseClusterDrsFaultsFaultsByVm{
+			&types.ClusterDrsFaultsFaultsByVm{
+				Fault: []types.LocalizedMethodFault{
+					types.LocalizedMethodFault{LocalizedMessage: "Insufficient disk space on datastore."},
+				},
+			},
+		},
+	}
+	if m := storageDrsFaultMessage(fault); m != ": storagePlacement: Insufficient disk space on datastore." {
+		t.Fatalf("bad: %s", m)
+	}
+}
+
+func TestNetworkReference(t *testing.T) {
+	cases := map[string]string{
+		"network-11":      "Network",
+		"dvportgroup-12":  "DistributedVirtualPortgroup",
+		"network-prod":    "",
+		"prod-network-11": "",
+		"VM Network":      "",
+	}
+
+	for label, expected := range cases {
+		ref, ok := networkReference(label)
+		if ok != (expected != "") || ref.Type != expected {
+			t.Errorf("bad reference for %s: %#v", label, ref)
+		}
+		if ok && ref.Value != label {
+			t.Errorf("bad value for %s: %s", label, ref.Value)
+		}
+	}
+}
+
 func testAccCheckVSphereVirtualMachineDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*govmomi.Client)
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
 	finder := find.NewFinder(client.Client, true)
 
 	for _, rs := range s.RootModule().Resources {
@@ -129,12 +512,12 @@ func testAccCheckVSphereVirtualMachineDestroy(s *terraform.State) error {
 			return fmt.Errorf("error %s", err)
 		}
 
-		dcFolders, err := dc.Folders(context.TODO())
+		folder, err := getVMFolder(client, dc, rs.Primary.Attributes["folder"])
 		if err != nil {
 			return fmt.Errorf("error %s", err)
 		}
 
-		_, err = object.NewSearchIndex(client.Client).FindChild(context.TODO(), dcFolders.VmFolder, rs.Primary.Attributes["name"])
+		_, err = object.NewSearchIndex(client.Client).FindChild(context.TODO(), folder, rs.Primary.Attributes["name"])
 		if err == nil {
 			return fmt.Errorf("Record still exists")
 		}
@@ -154,7 +537,7 @@ func testAccCheckVSphereVirtualMachineExists(n string, vm *virtualMachine) resou
 			return fmt.Errorf("No ID is set")
 		}
 
-		client := testAccProvider.Meta().(*govmomi.Client)
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
 		finder := find.NewFinder(client.Client, true)
 
 		dc, err := finder.Datacenter(context.TODO(), rs.Primary.Attributes["datacenter"])
@@ -162,12 +545,12 @@ func testAccCheckVSphereVirtualMachineExists(n string, vm *virtualMachine) resou
 			return fmt.Errorf("error %s", err)
 		}
 
-		dcFolders, err := dc.Folders(context.TODO())
+		folder, err := getVMFolder(client, dc, rs.Primary.Attributes["folder"])
 		if err != nil {
 			return fmt.Errorf("error %s", err)
 		}
 
-		_, err = object.NewSearchIndex(client.Client).FindChild(context.TODO(), dcFolders.VmFolder, rs.Primary.Attributes["name"])
+		_, err = object.NewSearchIndex(client.Client).FindChild(context.TODO(), folder, rs.Primary.Attributes["name"])
 		/*
 			vmRef, err := client.SearchIndex().FindChild(dcFolders.VmFolder, rs.Primary.Attributes["name"])
 			if err != nil {
@@ -238,3 +621,290 @@ resource "vsphere_virtual_machine" "bar" {
     }
 }
 `
+
+const testAccCheckVSphereVirtualMachineConfig_powerState = `
+resource "vsphere_virtual_machine" "baz" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    vcpu = 2
+    memory = 4096
+    network_interface {
+        label = "%s"
+    }
+    disk {
+        datastore = "%s"
+        template = "%s"
+    }
+    power_state = "%s"
+}
+`
+
+const testAccCheckVSphereVirtualMachineConfig_folder = `
+resource "vsphere_virtual_machine" "folder" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    folder = "%s"
+    vcpu = 2
+    memory = 4096
+    network_interface {
+        label = "%s"
+    }
+    disk {
+        datastore = "%s"
+        template = "%s"
+    }
+}
+`
+
+const testAccCheckVSphereVirtualMachineConfig_host = `
+resource "vsphere_virtual_machine" "host" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    host = "%s"
+    vcpu = 2
+    memory = 4096
+    network_interface {
+        label = "%s"
+    }
+    disk {
+        datastore = "%s"
+        template = "%s"
+    }
+}
+`
+
+const testAccCheckVSphereVirtualMachineConfig_relocate = `
+resource "vsphere_virtual_machine" "relocate" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    resource_pool = "%s"
+    vcpu = 2
+    memory = 4096
+    network_interface {
+        label = "%s"
+    }
+    disk {
+        datastore = "%s"
+        template = "%s"
+    }
+}
+`
+
+const testAccCheckVSphereVirtualMachineConfig_datastoreCluster = `
+resource "vsphere_virtual_machine" "sdrs" {
+    name = "terraform-test"
+    datacenter = "%s"
+    cluster = "%s"
+    vcpu = 2
+    memory = 4096
+    network_interface {
+        label = "%s"
+    }
+    disk {
+        datastore = "%s"
+        template = "%s"
+    }
+    disk {
+        datastore = "%s"
+        size = 1
+    }
+    disk {
+        datastore = "%s"
+        size = 1
+    }
+}
+`
+
+const testAccCheckVSphereVirtualMachineConfig_invalidNumCoresPerSocket = `
+resource "vsphere_virtual_machine" "qux" {
+    name = "terraform-test"
+    vcpu = 6
+    num_cores_per_socket = 4
+    memory = 4096
+    network_interface {
+        label = "label"
+    }
+    disk {
+        size = 1
+    }
+}
+`
+
+func TestCreateResourceAllocation(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, map[string]interface{}{
+		"cpu_reservation":    0,
+		"cpu_limit":          4000,
+		"cpu_share_level":    "custom",
+		"cpu_share_count":    2000,
+		"memory_share_level": "custom",
+	})
+
+	allocation, err := createResourceAllocation(d, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocation.Reservation != 0 || allocation.Limit != 4000 {
+		t.Fatalf("bad: %#v", allocation)
+	}
+	if allocation.Shares.Level != types.SharesLevelCustom || allocation.Shares.Shares != 2000 {
+		t.Fatalf("bad shares: %#v", allocation.Shares)
+	}
+
+	if _, err := createResourceAllocation(d, "memory"); err == nil {
+		t.Fatal("custom shares without memory_share_count should be an error")
+	}
+}
+
+func TestCreateStorageIOAllocation(t *testing.T) {
+	hd := hardDisk{ioShareLevel: "normal"}
+	allocation, err := hd.createStorageIOAllocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocation.Limit != -1 || allocation.Reservation != 0 || allocation.Shares.Level != types.SharesLevelNormal {
+		t.Fatalf("bad: %#v", allocation)
+	}
+
+	hd = hardDisk{iops: 500, ioReservation: 100, ioShareLevel: "custom", ioShareCount: 2000}
+	allocation, err = hd.createStorageIOAllocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocation.Limit != 500 || allocation.Reservation != 100 || allocation.Shares.Shares != 2000 {
+		t.Fatalf("bad: %#v", allocation)
+	}
+}
+
+func TestCreateSharesInfo(t *testing.T) {
+	for _, level := range []string{"low", "normal", "high"} {
+		shares, err := createSharesInfo("io", level, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(shares.Level) != level || shares.Shares != 0 {
+			t.Fatalf("bad: %#v", shares)
+		}
+	}
+
+	shares, err := createSharesInfo("io", "custom", 1500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares.Level != types.SharesLevelCustom || shares.Shares != 1500 {
+		t.Fatalf("bad: %#v", shares)
+	}
+
+	if _, err := createSharesInfo("io", "custom", 0); err == nil || !strings.Contains(err.Error(), "io_share_count") {
+		t.Fatalf("custom shares without io_share_count should be an error: %v", err)
+	}
+	if _, err := createSharesInfo("io", "medium", 0); err == nil {
+		t.Fatal("medium should be an invalid share level")
+	}
+}
+
+func TestCreateVAppPropertySpec(t *testing.T) {
+	defined := []types.VAppPropertyInfo{
+		{Key: 0, Id: "hostname", DefaultValue: "localhost"},
+		{Key: 1, Id: "dns", DefaultValue: "8.8.8.8"},
+		{Key: 2, Id: "version", DefaultValue: "1.0", UserConfigurable: types.NewBool(false)},
+	}
+
+	// hostname is set by key, and the removed dns is reset to its default value.
+	spec, err := createVAppPropertySpec(defined, map[string]string{"hostname": "web-1"}, []string{"dns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Property) != 2 {
+		t.Fatalf("bad: %#v", spec.Property)
+	}
+	for i, expected := range []types.VAppPropertyInfo{
+		{Key: 1, Id: "dns", Value: "8.8.8.8"},
+		{Key: 0, Id: "hostname", Value: "web-1"},
+	} {
+		p := spec.Property[i]
+		if p.Operation != types.ArrayUpdateOperationEdit || !reflect.DeepEqual(*p.Info, expected) {
+			t.Fatalf("bad property %d: %#v", i, p.Info)
+		}
+	}
+
+	if _, err := createVAppPropertySpec(defined, map[string]string{"host_name": "web-1"}, nil); err == nil || !strings.Contains(err.Error(), "Valid properties are: dns, hostname, version") {
+		t.Fatalf("unknown key should be an error with the valid keys: %v", err)
+	}
+	if _, err := createVAppPropertySpec(defined, map[string]string{"version": "2.0"}, nil); err == nil {
+		t.Fatal("a property which isn't user configurable should be an error")
+	}
+}
+
+func TestValidatePowerState(t *testing.T) {
+	validValues := []string{"on", "off", "suspended"}
+	for _, v := range validValues {
+		if _, errors := validatePowerState(v, "power_state"); len(errors) != 0 {
+			t.Fatalf("%q should be valid: %q", v, errors)
+		}
+	}
+
+	invalidValues := []string{"", "poweredOn", "Off"}
+	for _, v := range invalidValues {
+		if _, errors := validatePowerState(v, "power_state"); len(errors) == 0 {
+			t.Fatalf("%q should be invalid", v)
+		}
+	}
+}
+
+func TestCustomizationArguments(t *testing.T) {
+	raw := map[string]interface{}{
+		"network_interface": []interface{}{
+			map[string]interface{}{
+				"label": "VM Network",
+			},
+		},
+	}
+	d := schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, raw)
+	if args := customizationArguments(d.Get); len(args) != 0 {
+		t.Fatalf("no customization arguments should be set: %v", args)
+	}
+
+	raw["time_zone"] = "Asia/Tokyo"
+	raw["network_interface"] = []interface{}{
+		map[string]interface{}{
+			"label":           "VM Network",
+			"ip_address":      "192.168.0.10",
+			"dns_server_list": []interface{}{"192.168.0.1"},
+		},
+	}
+	d = schema.TestResourceDataRaw(t, resourceVSphereVirtualMachine().Schema, raw)
+	expected := []string{"time_zone", "network_interface.0.ip_address", "network_interface.0.dns_server_list"}
+	if args := customizationArguments(d.Get); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestVMFolderRelativePath(t *testing.T) {
+	entity := func(typ, name string) mo.ManagedEntity {
+		var e mo.ManagedEntity
+		e.Self = types.ManagedObjectReference{Type: typ}
+		e.Name = name
+		return e
+	}
+
+	// The datacenter is in a folder named vm, and so is the virtual machine.
+	entities := []mo.ManagedEntity{
+		entity("Folder", "Datacenters"),
+		entity("Folder", "vm"),
+		entity("Datacenter", "dc-1"),
+		entity("Folder", "vm"),
+		entity("Folder", "vm"),
+		entity("VirtualMachine", "web-1"),
+	}
+	if p, ok := vmFolderRelativePath(entities); !ok || p != "vm/web-1" {
+		t.Fatalf("bad: %q", p)
+	}
+
+	if _, ok := vmFolderRelativePath(entities[:2]); ok {
+		t.Fatal("an entity outside datacenters should have no path")
+	}
+}
@@ -0,0 +1,363 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+func resourceVSphereVmfsDatastore() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVSphereVmfsDatastoreCreate,
+		Read:   resourceVSphereVmfsDatastoreRead,
+		Update: resourceVSphereVmfsDatastoreUpdate,
+		Delete: resourceVSphereVmfsDatastoreDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVSphereVmfsDatastoreImport,
+		},
+
+		CustomizeDiff: resourceVSphereVmfsDatastoreCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: false,
+			},
+
+			"host_system_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"disks": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			"capacity": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"free_space": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVSphereVmfsDatastoreCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	hostIDs := stringSet(d.Get("host_system_ids").(*schema.Set))
+	disks := d.Get("disks").([]interface{})
+
+	// The datastore is created on a host and mounted on the others, which
+	// share the disks.
+	hds, err := hostDatastoreSystem(client, hostIDs[0])
+	if err != nil {
+		return err
+	}
+
+	devicePath, err := findVmfsDiskPath(client, hds, nil, disks[0].(string))
+	if err != nil {
+		return err
+	}
+	options, err := hds.QueryVmfsDatastoreCreateOptions(context.TODO(), devicePath)
+	if err != nil {
+		return err
+	}
+	if len(options) == 0 {
+		return fmt.Errorf("Disk %s can't be used to create a VMFS datastore.", disks[0])
+	}
+	spec, ok := options[0].Spec.(*types.VmfsDatastoreCreateSpec)
+	if !ok {
+		return fmt.Errorf("Unexpected VMFS datastore create spec: %#v", options[0].Spec)
+	}
+	spec.Vmfs.VolumeName = d.Get("name").(string)
+	log.Printf("[DEBUG] VMFS datastore create spec: %#v", spec)
+
+	ds, err := hds.CreateVmfsDatastore(context.TODO(), *spec)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(ds.Reference().Value)
+	log.Printf("[INFO] Created VMFS datastore: %s", d.Id())
+
+	for _, disk := range disks[1:] {
+		if err := extendVmfsDatastore(client, hds, ds.Reference(), disk.(string)); err != nil {
+			return err
+		}
+	}
+	for _, id := range hostIDs[1:] {
+		if err := mountVmfsDatastore(client, ds.Reference(), id); err != nil {
+			return err
+		}
+	}
+
+	return resourceVSphereVmfsDatastoreRead(d, meta)
+}
+
+func resourceVSphereVmfsDatastoreRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	mds, err := getDatastore(client, datastoreReference(d.Id()))
+	if err != nil {
+		if isManagedObjectNotFound(err) {
+			log.Printf("[ERROR] Datastore not found: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+	info, ok := mds.Info.(*types.VmfsDatastoreInfo)
+	if !ok || info.Vmfs == nil {
+		return fmt.Errorf("Datastore %s is not a VMFS datastore.", d.Id())
+	}
+
+	// A disk may have more than one partition in the datastore.
+	var disks []string
+	for _, extent := range info.Vmfs.Extent {
+		if len(disks) == 0 || disks[len(disks)-1] != extent.DiskName {
+			disks = append(disks, extent.DiskName)
+		}
+	}
+	d.Set("name", mds.Name)
+	d.Set("disks", disks)
+	d.Set("host_system_ids", mountedHostIDs(mds))
+	setDatastoreCapacity(d, mds)
+	return nil
+}
+
+func resourceVSphereVmfsDatastoreUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+	ref := datastoreReference(d.Id())
+
+	if d.HasChange("name") {
+		log.Printf("[INFO] Renaming datastore %s to %s", d.Id(), d.Get("name").(string))
+		if err := renameEntity(client, ref, d.Get("name").(string)); err != nil {
+			return err
+		}
+	}
+
+	// Hosts are mounted before the others are unmounted, so the datastore
+	// stays mounted on a host.
+	if d.HasChange("host_system_ids") {
+		o, n := d.GetChange("host_system_ids")
+		for _, id := range stringSet(n.(*schema.Set).Difference(o.(*schema.Set))) {
+			if err := mountVmfsDatastore(client, ref, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range stringSet(o.(*schema.Set).Difference(n.(*schema.Set))) {
+			if err := unmountVmfsDatastore(client, ref, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	if d.HasChange("disks") {
+		hds, err := hostDatastoreSystem(client, stringSet(d.Get("host_system_ids").(*schema.Set))[0])
+		if err != nil {
+			return err
+		}
+		// The disks are only appended, otherwise the datastore is recreated.
+		o, n := d.GetChange("disks")
+		for _, disk := range n.([]interface{})[len(o.([]interface{})):] {
+			if err := extendVmfsDatastore(client, hds, ref, disk.(string)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceVSphereVmfsDatastoreRead(d, meta)
+}
+
+func resourceVSphereVmfsDatastoreDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*VSphereClient).vimClient
+
+	ref := datastoreReference(d.Id())
+
+	mds, err := getDatastore(client, ref)
+	if err != nil {
+		return err
+	}
+	hostIDs := mountedHostIDs(mds)
+	if len(hostIDs) == 0 {
+		return fmt.Errorf("Datastore %s is not mounted on any host.", d.Id())
+	}
+
+	// Removing a VMFS datastore from a host deletes it from all the hosts.
+	log.Printf("[INFO] Deleting VMFS datastore: %s", d.Id())
+	if err := removeDatastore(client, ref, hostIDs[:1]); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// resourceVSphereVmfsDatastoreImport imports a VMFS datastore by its inventory
+// path, such as "/Datacenter/datastore/vmfs-1".
+func resourceVSphereVmfsDatastoreImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*VSphereClient).vimClient
+
+	ref, err := findDatastoreByPath(client, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(ref.Value)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceVSphereVmfsDatastoreCustomizeDiff recreates the datastore when
+// disks are removed or reordered, because a VMFS datastore can only be
+// extended.
+func resourceVSphereVmfsDatastoreCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("disks") {
+		return nil
+	}
+	o, n := d.GetChange("disks")
+	if !isVmfsExtension(o.([]interface{}), n.([]interface{})) {
+		return d.ForceNew("disks")
+	}
+	return nil
+}
+
+// isVmfsExtension returns true if the new disks are the old disks followed by
+// additional disks.
+func isVmfsExtension(old, new []interface{}) bool {
+	if len(new) < len(old) {
+		return false
+	}
+	for i := range old {
+		if old[i] != new[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// findVmfsDiskPath finds the device path of a disk available for VMFS on a
+// host by its canonical name, such as "naa.60003ff44dc75adc". If datastore is
+// given, the disks available to extend it are searched.
+func findVmfsDiskPath(c *govmomi.Client, hds *object.HostDatastoreSystem, datastore *types.ManagedObjectReference, name string) (string, error) {
+	res, err := methods.QueryAvailableDisksForVmfs(context.TODO(), c.Client, &types.QueryAvailableDisksForVmfs{
+		This:      hds.Reference(),
+		Datastore: datastore,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, disk := range res.Returnval {
+		if disk.CanonicalName == name {
+			return disk.DevicePath, nil
+		}
+	}
+	return "", fmt.Errorf("Disk %s is not available for VMFS.", name)
+}
+
+// extendVmfsDatastore extends a VMFS datastore with a disk.
+func extendVmfsDatastore(c *govmomi.Client, hds *object.HostDatastoreSystem, ref types.ManagedObjectReference, disk string) error {
+	devicePath, err := findVmfsDiskPath(c, hds, &ref, disk)
+	if err != nil {
+		return err
+	}
+
+	res, err := methods.QueryVmfsDatastoreExtendOptions(context.TODO(), c.Client, &types.QueryVmfsDatastoreExtendOptions{
+		This:                     hds.Reference(),
+		Datastore:                ref,
+		DevicePath:               devicePath,
+		SuppressExpandCandidates: types.NewBool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Returnval) == 0 {
+		return fmt.Errorf("Disk %s can't be used to extend datastore %s.", disk, ref.Value)
+	}
+	spec, ok := res.Returnval[0].Spec.(*types.VmfsDatastoreExtendSpec)
+	if !ok {
+		return fmt.Errorf("Unexpected VMFS datastore extend spec: %#v", res.Returnval[0].Spec)
+	}
+
+	log.Printf("[INFO] Extending datastore %s with disk %s", ref.Value, disk)
+	_, err = methods.ExtendVmfsDatastore(context.TODO(), c.Client, &types.ExtendVmfsDatastore{
+		This:      hds.Reference(),
+		Datastore: ref,
+		Spec:      *spec,
+	})
+	return err
+}
+
+// mountVmfsDatastore mounts a VMFS datastore on a host which shares its disks.
+// The host rescans the disks for VMFS volumes, which may mount the datastore.
+func mountVmfsDatastore(c *govmomi.Client, ref types.ManagedObjectReference, hostID string) error {
+	hss, err := object.NewHostSystem(c.Client, hostSystemReference(hostID)).ConfigManager().StorageSystem(context.TODO())
+	if err != nil {
+		return err
+	}
+	if _, err := methods.RescanVmfs(context.TODO(), c.Client, &types.RescanVmfs{This: hss.Reference()}); err != nil {
+		return err
+	}
+
+	mds, err := getDatastore(c, ref)
+	if err != nil {
+		return err
+	}
+	for _, id := range mountedHostIDs(mds) {
+		if id == hostID {
+			return nil
+		}
+	}
+
+	log.Printf("[INFO] Mounting VMFS datastore %s on host %s", ref.Value, hostID)
+	_, err = methods.MountVmfsVolume(context.TODO(), c.Client, &types.MountVmfsVolume{
+		This:     hss.Reference(),
+		VmfsUuid: vmfsUUID(mds),
+	})
+	return err
+}
+
+// unmountVmfsDatastore unmounts a VMFS datastore from a host. The datastore
+// and its data are kept.
+func unmountVmfsDatastore(c *govmomi.Client, ref types.ManagedObjectReference, hostID string) error {
+	hss, err := object.NewHostSystem(c.Client, hostSystemReference(hostID)).ConfigManager().StorageSystem(context.TODO())
+	if err != nil {
+		return err
+	}
+	mds, err := getDatastore(c, ref)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Unmounting VMFS datastore %s from host %s", ref.Value, hostID)
+	_, err = methods.UnmountVmfsVolume(context.TODO(), c.Client, &types.UnmountVmfsVolume{
+		This:     hss.Reference(),
+		VmfsUuid: vmfsUUID(mds),
+	})
+	return err
+}
+
+// vmfsUUID returns the UUID of the VMFS volume of a datastore.
+func vmfsUUID(mds *mo.Datastore) string {
+	if info, ok := mds.Info.(*types.VmfsDatastoreInfo); ok && info.Vmfs != nil {
+		return info.Vmfs.Uuid
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVSphereVmfsDatastore_basic(t *testing.T) {
+	datacenter := os.Getenv("VSPHERE_DATACENTER")
+	hostID := os.Getenv("VSPHERE_HOST_ID")
+	disk := os.Getenv("VSPHERE_VMFS_DISK")
+	disk2 := os.Getenv("VSPHERE_VMFS_DISK2")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVSphereVmfsDatastoreDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVmfsDatastoreConfig_basic,
+					hostID,
+					fmt.Sprintf(`"%s"`, disk),
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVmfsDatastoreExists("vsphere_vmfs_datastore.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_vmfs_datastore.foo", "disks.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vsphere_vmfs_datastore.foo", "host_system_ids.#", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(
+					testAccCheckVSphereVmfsDatastoreConfig_basic,
+					hostID,
+					fmt.Sprintf(`"%s", "%s"`, disk, disk2),
+				),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVSphereVmfsDatastoreExists("vsphere_vmfs_datastore.foo"),
+					resource.TestCheckResourceAttr(
+						"vsphere_vmfs_datastore.foo", "disks.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vsphere_vmfs_datastore.foo", "disks.1", disk2),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vsphere_vmfs_datastore.foo",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("/%s/datastore/terraform-test", datacenter),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestIsVmfsExtension(t *testing.T) {
+	cases := []struct {
+		old, new []interface{}
+		expected bool
+	}{
+		{[]interface{}{"a"}, []interface{}{"a", "b"}, true},
+		{[]interface{}{"a", "b"}, []interface{}{"a", "b"}, true},
+		{[]interface{}{"a", "b"}, []interface{}{"a"}, false},
+		{[]interface{}{"a", "b"}, []interface{}{"b", "a"}, false},
+		{[]interface{}{"a"}, []interface{}{"b", "a"}, false},
+	}
+
+	for _, c := range cases {
+		if actual := isVmfsExtension(c.old, c.new); actual != c.expected {
+			t.Errorf("isVmfsExtension(%v, %v) = %t, expected %t", c.old, c.new, actual, c.expected)
+		}
+	}
+}
+
+func testAccCheckVSphereVmfsDatastoreDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*VSphereClient).vimClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vsphere_vmfs_datastore" {
+			continue
+		}
+
+		_, err := getDatastore(client, datastoreReference(rs.Primary.ID))
+		if err == nil {
+			return fmt.Errorf("Record still exists")
+		}
+		if !isManagedObjectNotFound(err) {
+			return fmt.Errorf("error %s", err)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVSphereVmfsDatastoreExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		client := testAccProvider.Meta().(*VSphereClient).vimClient
+		if _, err := getDatastore(client, datastoreReference(rs.Primary.ID)); err != nil {
+			return fmt.Errorf("error %s", err)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVSphereVmfsDatastoreConfig_basic = `
+resource "vsphere_vmfs_datastore" "foo" {
+    name = "terraform-test"
+    host_system_ids = ["%s"]
+    disks = [%s]
+}
+`
@@ -0,0 +1,177 @@
+package vsphere
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const restSessionHeader = "vmware-api-session-id"
+
+// restClient is a client for the vSphere Automation REST API of vCenter
+// Server, which provides the APIs that are not in the SOAP API, such as
+// Content Library and tagging. It logs in on the first request.
+type restClient struct {
+	url        *url.URL
+	user       string
+	password   string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	session string
+}
+
+// restError is an error response of the REST API.
+type restError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *restError) Error() string {
+	return fmt.Sprintf("REST API error: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// isRestNotFound returns true if err is a 404 response of the REST API.
+func isRestNotFound(err error) bool {
+	e, ok := err.(*restError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// newRestClient creates a new restClient for the vCenter Server.
+func newRestClient(server, user, password string, insecure bool) (*restClient, error) {
+	u, err := url.Parse("https://" + server + "/rest")
+	if err != nil {
+		return nil, fmt.Errorf("Error parse url: %s", err)
+	}
+	return &restClient{
+		url:      u,
+		user:     user,
+		password: password,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			},
+		},
+	}, nil
+}
+
+// login creates a new API session.
+func (c *restClient) login() error {
+	req, err := http.NewRequest("POST", c.url.String()+"/com/vmware/cis/session", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.user, c.password)
+
+	var session string
+	if err := c.send(req, &session); err != nil {
+		return fmt.Errorf("Error logging in to REST API: %s", err)
+	}
+	c.session = session
+	return nil
+}
+
+// do sends a request to path with in as the JSON body, and decodes the
+// "value" of the response into out. in and out can be nil. The session
+// is renewed once if it has expired.
+func (c *restClient) do(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	// The body isn't logged because it can contain passwords.
+	log.Printf("[DEBUG] REST API request: %s %s", method, path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for retry := true; ; retry = false {
+		if c.session == "" {
+			if err := c.login(); err != nil {
+				return err
+			}
+		}
+
+		req, err := http.NewRequest(method, c.url.String()+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(restSessionHeader, c.session)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		err = c.send(req, out)
+		if e, ok := err.(*restError); ok && e.StatusCode == http.StatusUnauthorized && retry {
+			c.session = ""
+			continue
+		}
+		return err
+	}
+}
+
+// send sends req and decodes the "value" of the response into out.
+func (c *restClient) send(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &restError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+	if out == nil {
+		return nil
+	}
+
+	value := struct {
+		Value interface{} `json:"value"`
+	}{out}
+	return json.NewDecoder(resp.Body).Decode(&value)
+}
+
+// upload uploads the content of r to u, such as an upload endpoint of
+// Content Library, in the current session.
+func (c *restClient) upload(u string, r io.Reader, size int64) error {
+	req, err := http.NewRequest("PUT", u, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	c.mu.Lock()
+	if c.session == "" {
+		if err := c.login(); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	req.Header.Set(restSessionHeader, c.session)
+	c.mu.Unlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &restError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+	return nil
+}
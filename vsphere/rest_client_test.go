@@ -0,0 +1,59 @@
+package vsphere
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRestClient_renewSession(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/com/vmware/cis/session":
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			logins++
+			if logins == 1 {
+				w.Write([]byte(`{"value": "expired"}`))
+			} else {
+				w.Write([]byte(`{"value": "session"}`))
+			}
+		case "/rest/com/vmware/content/library/id:lib":
+			if r.Header.Get(restSessionHeader) != "session" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"value": {"id": "lib", "name": "foo"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL + "/rest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	c := &restClient{
+		url:        u,
+		user:       "user",
+		password:   "pass",
+		httpClient: http.DefaultClient,
+	}
+
+	library, err := getContentLibrary(c, "lib")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if library.Name != "foo" || logins != 2 {
+		t.Fatalf("bad: %#v (%d logins)", library, logins)
+	}
+
+	if _, err := getContentLibrary(c, "missing"); !isRestNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
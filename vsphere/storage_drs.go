@@ -0,0 +1,137 @@
+package vsphere
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"golang.org/x/net/context"
+)
+
+// storagePodReference returns the reference of a datastore cluster by its managed object ID.
+func storagePodReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "StoragePod",
+		Value: id,
+	}
+}
+
+// datastoreReference returns the reference of a datastore by its managed object ID.
+func datastoreReference(id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "Datastore",
+		Value: id,
+	}
+}
+
+// getStoragePod gets a datastore cluster with its member datastores and Storage DRS configuration.
+func getStoragePod(c *govmomi.Client, ref types.ManagedObjectReference) (*mo.StoragePod, error) {
+	var msp mo.StoragePod
+	pod := object.NewFolder(c.Client, ref)
+	if err := pod.Properties(context.TODO(), ref, []string{"name", "childEntity", "podStorageDrsEntry"}, &msp); err != nil {
+		return nil, err
+	}
+	if msp.PodStorageDrsEntry == nil {
+		return nil, fmt.Errorf("Storage DRS configuration of datastore cluster %s is not found.", ref.Value)
+	}
+	return &msp, nil
+}
+
+// configureStorageDrs applies the changes of spec to a datastore cluster.
+func configureStorageDrs(c *govmomi.Client, ref types.ManagedObjectReference, spec types.StorageDrsConfigSpec) error {
+	log.Printf("[DEBUG] Storage DRS config spec: %#v", spec)
+	srm := object.NewStorageResourceManager(c.Client)
+	pod := &object.StoragePod{Folder: object.NewFolder(c.Client, ref)}
+	task, err := srm.ConfigureStorageDrsForPod(context.TODO(), pod, spec, true)
+	return waitForTask(task, err)
+}
+
+// findStorageDrsVMConfig finds the Storage DRS settings of a virtual machine
+// in a datastore cluster. It returns nil if not found.
+func findStorageDrsVMConfig(msp *mo.StoragePod, vm types.ManagedObjectReference) *types.StorageDrsVmConfigInfo {
+	for _, v := range msp.PodStorageDrsEntry.StorageDrsConfig.VmConfig {
+		if v.Vm != nil && *v.Vm == vm {
+			info := v
+			return &info
+		}
+	}
+	return nil
+}
+
+// setStorageDrsVMConfig changes the Storage DRS settings of a virtual machine
+// in a datastore cluster with update. The virtual machine overrides and the
+// VMDK anti-affinity rule share the settings, so each of them changes only
+// its own fields. The settings are removed when update clears all of them.
+func setStorageDrsVMConfig(c *govmomi.Client, pod, vm types.ManagedObjectReference, update func(*types.StorageDrsVmConfigInfo)) error {
+	msp, err := getStoragePod(c, pod)
+	if err != nil {
+		return err
+	}
+
+	current := findStorageDrsVMConfig(msp, vm)
+	info := &types.StorageDrsVmConfigInfo{Vm: &vm}
+	if current != nil {
+		*info = *current
+	}
+	update(info)
+
+	spec := createStorageDrsVMConfigSpec(current, info)
+	if len(spec.VmConfigSpec) == 0 {
+		return nil
+	}
+	return configureStorageDrs(c, pod, spec)
+}
+
+// createStorageDrsVMConfigSpec creates StorageDrsConfigSpec to change the
+// Storage DRS settings of a virtual machine from current to info. The changes
+// are sent in one spec, so the other settings are kept if it fails.
+func createStorageDrsVMConfigSpec(current, info *types.StorageDrsVmConfigInfo) types.StorageDrsConfigSpec {
+	var specs []types.StorageDrsVmConfigSpec
+	remove := types.StorageDrsVmConfigSpec{
+		ArrayUpdateSpec: types.ArrayUpdateSpec{
+			Operation: types.ArrayUpdateOperationRemove,
+			RemoveKey: *info.Vm,
+		},
+	}
+	cleared := info.Enabled == nil && info.Behavior == "" && info.IntraVmAffinity == nil && info.IntraVmAntiAffinity == nil
+
+	switch {
+	case current == nil && cleared:
+	case current == nil:
+		specs = append(specs, types.StorageDrsVmConfigSpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+			Info:            info,
+		})
+	case cleared:
+		specs = append(specs, remove)
+	case (current.Enabled != nil && info.Enabled == nil) ||
+		(current.Behavior != "" && info.Behavior == "") ||
+		(current.IntraVmAffinity != nil && info.IntraVmAffinity == nil) ||
+		(current.IntraVmAntiAffinity != nil && info.IntraVmAntiAffinity == nil):
+		// Unset fields of an edit are left unchanged, so the settings are
+		// replaced to clear a field.
+		specs = append(specs, remove, types.StorageDrsVmConfigSpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+			Info:            info,
+		})
+	default:
+		specs = append(specs, types.StorageDrsVmConfigSpec{
+			ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationEdit},
+			Info:            info,
+		})
+	}
+	return types.StorageDrsConfigSpec{VmConfigSpec: specs}
+}
+
+// validateStorageDrsBehavior validates the Storage DRS automation level.
+func validateStorageDrsBehavior(v interface{}, k string) (ws []string, errors []error) {
+	switch types.StorageDrsPodConfigInfoBehavior(v.(string)) {
+	case types.StorageDrsPodConfigInfoBehaviorManual, types.StorageDrsPodConfigInfoBehaviorAutomated:
+	default:
+		errors = append(errors, fmt.Errorf("%q must be %q or %q", k, types.StorageDrsPodConfigInfoBehaviorManual, types.StorageDrsPodConfigInfoBehaviorAutomated))
+	}
+	return
+}
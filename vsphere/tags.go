@@ -0,0 +1,91 @@
+package vsphere
+
+import (
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// tagsSchema returns the schema of the tags argument, which is a set of tag IDs.
+func tagsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+		Set:      schema.HashString,
+	}
+}
+
+// tagObjectID is the ID of a managed object in the tagging API.
+type tagObjectID struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// getTags gets the IDs of the tags attached to a managed object.
+func getTags(c *restClient, ref types.ManagedObjectReference) ([]string, error) {
+	var tags []string
+	body := map[string]interface{}{
+		"object_id": tagObjectID{ID: ref.Value, Type: ref.Type},
+	}
+	err := c.do("POST", "/com/vmware/cis/tagging/tag-association?~action=list-attached-tags", body, &tags)
+	return tags, err
+}
+
+// setTags attaches the tags in new and detaches the tags only in old.
+func setTags(c *restClient, ref types.ManagedObjectReference, old, new []string) error {
+	body := map[string]interface{}{
+		"object_id": tagObjectID{ID: ref.Value, Type: ref.Type},
+	}
+
+	attached := make(map[string]bool)
+	for _, tag := range new {
+		attached[tag] = true
+	}
+	for _, tag := range old {
+		if attached[tag] {
+			continue
+		}
+		log.Printf("[DEBUG] detaching tag %s from %s", tag, ref)
+		if err := c.do("POST", "/com/vmware/cis/tagging/tag-association/id:"+tag+"?~action=detach", body, nil); err != nil {
+			return err
+		}
+	}
+	for _, tag := range new {
+		log.Printf("[DEBUG] attaching tag %s to %s", tag, ref)
+		if err := c.do("POST", "/com/vmware/cis/tagging/tag-association/id:"+tag+"?~action=attach", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterTags returns the tags which are in managed.
+func filterTags(tags []string, managed *schema.Set) []string {
+	var l []string
+	for _, tag := range tags {
+		if managed.Contains(tag) {
+			l = append(l, tag)
+		}
+	}
+	return l
+}
+
+// applyTags attaches and detaches tags of a managed object by the changes of the tags argument.
+func applyTags(c *restClient, ref types.ManagedObjectReference, d *schema.ResourceData) error {
+	if !d.HasChange("tags") {
+		return nil
+	}
+	old, new := d.GetChange("tags")
+	return setTags(c, ref, stringSet(old.(*schema.Set)), stringSet(new.(*schema.Set)))
+}
+
+// stringSet returns the elements of a set of strings.
+func stringSet(s *schema.Set) []string {
+	var l []string
+	for _, v := range s.List() {
+		l = append(l, v.(string))
+	}
+	return l
+}